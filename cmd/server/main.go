@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -52,13 +53,34 @@ func main() {
 	}
 
 	client := connectMongo(mongoURI)
+	if ok, err := repo.Transactions(context.Background(), client); err != nil || !ok {
+		log.Println("MongoDB runs without transactions (not a replica set): swaps, bulk shifts and draft publishing will fail with 503 transactions_unavailable")
+	}
 	db := client.Database(dbName)
 	coll := db.Collection("schedules")
 
 	mrepo := repo.NewMongoRepo(coll)
 	h := handlers.NewHandler(mrepo, coll)
+	if err := h.AdoptOrphans(); err != nil {
+		log.Println("assign existing data to the default school:", err)
+	}
+	if err := h.BackfillInstants(); err != nil {
+		log.Println("backfill start_at/end_at:", err)
+	}
+
+	if h.Cfg.EventsChangeStream {
+		if err := repo.Watch(context.Background(), coll, h.Events.Publish); err != nil {
+			log.Println("change stream unavailable, handlers publish events:", err)
+			h.Cfg.EventsChangeStream = false
+		}
+	}
+
+	go h.Dispatcher.Run(context.Background(), h.Events)
+	go h.Notifier().Run(context.Background(), h.Events)
+	go h.RunDigests(context.Background())
 
 	r := gin.Default()
+	r.Use(middleware.RequestID(), middleware.Recovery())
 
 	// swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -77,11 +99,113 @@ func main() {
 			s.GET("", h.GetAll)
 			s.GET("/:uuid", h.GetByUUID)
 			s.PUT("/:uuid", h.Update)
+			s.PATCH("/:uuid", h.Patch)
 			s.DELETE("/:uuid", h.Delete)
 			s.GET("/student", h.StudentSchedule)
 			s.GET("/teacher", h.TeacherSchedule)
 			s.GET("/export", h.ExportJP)
 			s.POST("/import", h.ImportExcel)
+			s.GET("/free-slots", h.FreeSlots)
+			s.POST("/swap", h.Swap)
+			s.GET("/:uuid/attendance", h.LessonAttendance)
+			s.PUT("/:uuid/attendance", h.SubmitAttendance)
+			s.GET("/:uuid/journal", h.LessonJournal)
+			s.PUT("/:uuid/journal", h.SubmitJournal)
+			s.POST("/copy", h.CopySchedules)
+			s.POST("/bulk/delete", h.BulkDelete)
+			s.POST("/bulk/shift", h.BulkShift)
+			s.POST("/:uuid/move", h.Move)
+			s.GET("/pdf/class", h.ClassTimetablePDF)
+			s.GET("/pdf/teacher", h.TeacherTimetablePDF)
+			s.GET("/pdf/classes", h.AllClassesTimetablePDF)
+		}
+
+		api.GET("/reports/jp", h.Report)
+		api.GET("/workload", h.Workload)
+		api.GET("/audit", h.AuditLog)
+		api.GET("/events", h.StreamEvents)
+		api.POST("/notifications/digest", h.SendDigest)
+		api.GET("/attendance/class", h.ClassAttendance)
+		api.GET("/attendance/student", h.StudentAttendance)
+		api.GET("/journal", h.ListJournal)
+		api.GET("/school", h.GetSchool)
+		api.PUT("/school", h.PutSchool)
+		api.GET("/academic-years", h.ListAcademicYears)
+		api.POST("/academic-years", h.AddAcademicYear)
+		api.GET("/terms", h.ListTerms)
+		api.POST("/terms", h.AddTerm)
+		api.GET("/terms/:id", h.GetTerm)
+		api.POST("/terms/:id/lock", h.LockTerm)
+		api.POST("/terms/:id/unlock", h.UnlockTerm)
+
+		d := api.Group("/drafts")
+		{
+			d.GET("", h.ListDrafts)
+			d.POST("", h.OpenDraft)
+			d.GET("/:id", h.GetDraft)
+			d.GET("/:id/diff", h.DraftDiff)
+			d.POST("/:id/publish", h.PublishDraft)
+			d.DELETE("/:id", h.DiscardDraft)
+		}
+
+		wh := api.Group("/webhooks")
+		{
+			wh.GET("", h.ListWebhooks)
+			wh.POST("", h.AddWebhook)
+			wh.GET("/:id", h.GetWebhook)
+			wh.PATCH("/:id", h.SetWebhookActive)
+			wh.DELETE("/:id", h.DeleteWebhook)
+			wh.POST("/:id/ping", h.PingWebhook)
+			wh.GET("/:id/deliveries", h.WebhookDeliveries)
+		}
+		wd := api.Group("/webhook-deliveries")
+		{
+			wd.GET("", h.ListDeliveries)
+			wd.GET("/dead", h.DeadLetters)
+			wd.POST("/:id/retry", h.RetryDelivery)
+		}
+
+		cr := api.Group("/change-requests")
+		{
+			cr.GET("", h.ListChangeRequests)
+			cr.POST("", h.SubmitChangeRequest)
+			cr.GET("/:id", h.GetChangeRequest)
+			cr.POST("/:id/approve", h.ApproveChangeRequest)
+			cr.POST("/:id/reject", h.RejectChangeRequest)
+		}
+		api.GET("/bell-schedule", h.GetBellSchedule)
+		api.PUT("/bell-schedule", h.PutBellSchedule)
+		api.GET("/holidays", h.ListHolidays)
+		api.POST("/holidays", h.AddHoliday)
+		api.DELETE("/holidays/:id", h.DeleteHoliday)
+
+		t := api.Group("/teachers")
+		{
+			t.GET("", h.ListTeachers)
+			t.GET("/:nik", h.GetTeacher)
+			t.PUT("/:nik", h.PutTeacher)
+			t.GET("/:nik/availability", h.GetAvailability)
+			t.POST("/:nik/availability", h.AddAvailabilityWindow)
+			t.DELETE("/:nik/availability/:id", h.DeleteAvailabilityWindow)
+			t.POST("/:nik/unavailability", h.AddUnavailability)
+			t.DELETE("/:nik/unavailability/:id", h.DeleteUnavailability)
+		}
+
+		cur := api.Group("/curriculum")
+		{
+			cur.GET("", h.ListCurriculum)
+			cur.PUT("", h.PutCurriculum)
+			cur.DELETE("/:id", h.DeleteCurriculum)
+			cur.GET("/coverage", h.CurriculumCoverage)
+		}
+
+		hon := api.Group("/honorarium")
+		{
+			hon.GET("", h.Honorarium)
+			hon.GET("/export", h.ExportHonorarium)
+			hon.GET("/rates", h.ListRates)
+			hon.PUT("/rates", h.PutRate)
+			hon.DELETE("/rates/:id", h.DeleteRate)
 		}
 	}
 
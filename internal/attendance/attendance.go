@@ -0,0 +1,106 @@
+// Package attendance summarizes attendance records per student, class or
+// subject.
+package attendance
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/report"
+)
+
+// Summary counts the statuses of one student, class or subject. Rate is
+// the share of hadir in percent.
+type Summary struct {
+	Key   string  `json:"key"`
+	Name  string  `json:"name,omitempty"`
+	Hadir int     `json:"hadir"`
+	Izin  int     `json:"izin"`
+	Sakit int     `json:"sakit"`
+	Alpa  int     `json:"alpa"`
+	Total int     `json:"total"`
+	Rate  float64 `json:"rate"`
+}
+
+func (s *Summary) add(status string) {
+	switch status {
+	case models.AttendanceHadir:
+		s.Hadir++
+	case models.AttendanceIzin:
+		s.Izin++
+	case models.AttendanceSakit:
+		s.Sakit++
+	case models.AttendanceAlpa:
+		s.Alpa++
+	}
+	s.Total++
+	s.Rate = math.Round(float64(s.Hadir)/float64(s.Total)*10000) / 100
+}
+
+// Total sums all records into one summary under key.
+func Total(key string, records []models.Attendance) Summary {
+	s := Summary{Key: key}
+	for _, r := range records {
+		s.add(r.Status)
+	}
+	return s
+}
+
+// summarize groups records by key, sorted by key. name is the display
+// name of a group, taken from its latest record.
+func summarize(records []models.Attendance, key func(models.Attendance) (string, string)) []Summary {
+	groups := map[string]*Summary{}
+	for _, r := range records {
+		k, name := key(r)
+		s, ok := groups[k]
+		if !ok {
+			s = &Summary{Key: k}
+			groups[k] = s
+		}
+		if name != "" {
+			s.Name = name
+		}
+		s.add(r.Status)
+	}
+	out := make([]Summary, 0, len(groups))
+	for _, s := range groups {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// ByStudent summarizes per student NIS.
+func ByStudent(records []models.Attendance) []Summary {
+	return summarize(records, func(r models.Attendance) (string, string) { return r.StudentNIS, r.StudentName })
+}
+
+// ByClass summarizes per class.
+func ByClass(records []models.Attendance) []Summary {
+	return summarize(records, func(r models.Attendance) (string, string) { return r.ClassCode, "" })
+}
+
+// BySubject summarizes per subject.
+func BySubject(records []models.Attendance) []Summary {
+	return summarize(records, func(r models.Attendance) (string, string) { return r.SubjectCode, "" })
+}
+
+// Lessons counts the distinct lessons the records belong to.
+func Lessons(records []models.Attendance) int {
+	seen := map[string]bool{}
+	for _, r := range records {
+		seen[r.ScheduleUUID] = true
+	}
+	return len(seen)
+}
+
+// Table lays summaries out for export, keyed by a column named key and
+// followed by total as the last row.
+func Table(key string, rows []Summary, total Summary) report.Table {
+	t := report.Table{Columns: []string{key, "name", "hadir", "izin", "sakit", "alpa", "total", "rate"}}
+	for _, s := range append(rows, total) {
+		t.Rows = append(t.Rows, []interface{}{s.Key, s.Name, s.Hadir, s.Izin, s.Sakit, s.Alpa, s.Total, s.Rate})
+	}
+	return t
+}
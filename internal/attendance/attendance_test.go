@@ -0,0 +1,61 @@
+package attendance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func rec(uuid, nis, name, class, subject, status string) models.Attendance {
+	return models.Attendance{ScheduleUUID: uuid, StudentNIS: nis, StudentName: name, ClassCode: class, SubjectCode: subject, Status: status}
+}
+
+var records = []models.Attendance{
+	rec("l1", "001", "Ani", "X-1", "MTK", models.AttendanceHadir),
+	rec("l1", "002", "Budi", "X-1", "MTK", models.AttendanceSakit),
+	rec("l2", "001", "Ani", "X-1", "BIN", models.AttendanceHadir),
+	rec("l2", "002", "Budi", "X-1", "BIN", models.AttendanceAlpa),
+	rec("l3", "003", "Citra", "X-2", "MTK", models.AttendanceIzin),
+	rec("l4", "003", "Citra", "X-2", "MTK", models.AttendanceHadir),
+}
+
+func TestByStudent(t *testing.T) {
+	rows := ByStudent(records)
+	assert.Equal(t, []Summary{
+		{Key: "001", Name: "Ani", Hadir: 2, Total: 2, Rate: 100},
+		{Key: "002", Name: "Budi", Sakit: 1, Alpa: 1, Total: 2, Rate: 0},
+		{Key: "003", Name: "Citra", Hadir: 1, Izin: 1, Total: 2, Rate: 50},
+	}, rows)
+}
+
+func TestByClassAndSubject(t *testing.T) {
+	classes := ByClass(records)
+	assert.Len(t, classes, 2)
+	assert.Equal(t, Summary{Key: "X-1", Hadir: 2, Sakit: 1, Alpa: 1, Total: 4, Rate: 50}, classes[0])
+
+	subjects := BySubject(records)
+	assert.Equal(t, "BIN", subjects[0].Key)
+	assert.Equal(t, 4, subjects[1].Total)
+
+	total := Total("X-1", records[:4])
+	assert.Equal(t, 4, total.Total)
+	assert.Equal(t, 2, Lessons(records[:4]))
+}
+
+func TestRateRounding(t *testing.T) {
+	s := Total("x", []models.Attendance{
+		{Status: models.AttendanceHadir}, {Status: models.AttendanceHadir}, {Status: models.AttendanceAlpa},
+	})
+	assert.Equal(t, 66.67, s.Rate)
+	assert.Zero(t, Total("empty", nil).Rate)
+}
+
+func TestTable(t *testing.T) {
+	rows := ByStudent(records[:2])
+	tbl := Table("student_nis", rows, Total("total", records[:2]))
+	assert.Equal(t, []string{"student_nis", "name", "hadir", "izin", "sakit", "alpa", "total", "rate"}, tbl.Columns)
+	assert.Len(t, tbl.Rows, 3)
+	assert.Equal(t, []interface{}{"total", "", 1, 0, 1, 0, 2, 50.0}, tbl.Rows[2])
+}
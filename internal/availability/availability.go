@@ -0,0 +1,53 @@
+package availability
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+var dayNames = []string{"", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// ISOWeekday returns 1 for Monday through 7 for Sunday.
+func ISOWeekday(d time.Time) int {
+	return (int(d.Weekday())+6)%7 + 1
+}
+
+func covers(w models.AvailabilityWindow, weekday, jamKe int) bool {
+	if w.Weekday != weekday {
+		return false
+	}
+	if w.JamKeFrom > 0 && jamKe < w.JamKeFrom {
+		return false
+	}
+	if w.JamKeTo > 0 && jamKe > w.JamKeTo {
+		return false
+	}
+	return true
+}
+
+// Check returns why the teacher cannot teach period jamKe on date, or ""
+// when they can. windows and leaves must belong to the same teacher.
+func Check(windows []models.AvailabilityWindow, leaves []models.Unavailability, date time.Time, jamKe int) string {
+	for _, l := range leaves {
+		if !date.Before(l.StartDate) && !date.After(l.EndDate) {
+			reason := l.Reason
+			if reason == "" {
+				reason = "unavailable"
+			}
+			return fmt.Sprintf("teacher unavailable %s to %s: %s",
+				l.StartDate.Format("2006-01-02"), l.EndDate.Format("2006-01-02"), reason)
+		}
+	}
+	if len(windows) == 0 {
+		return ""
+	}
+	weekday := ISOWeekday(date)
+	for _, w := range windows {
+		if covers(w, weekday, jamKe) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("teacher not available on %s jam ke-%d", dayNames[weekday], jamKe)
+}
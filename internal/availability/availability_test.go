@@ -0,0 +1,31 @@
+package availability_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/availability"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestCheck(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	d := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+
+	assert.Equal(t, "", availability.Check(nil, nil, d(1), 3))
+
+	windows := []models.AvailabilityWindow{
+		{Weekday: 1, JamKeFrom: 1, JamKeTo: 4},
+		{Weekday: 3},
+	}
+	assert.Equal(t, "", availability.Check(windows, nil, d(1), 4))
+	assert.Equal(t, "teacher not available on Senin jam ke-5", availability.Check(windows, nil, d(1), 5))
+	assert.Equal(t, "teacher not available on Selasa jam ke-1", availability.Check(windows, nil, d(2), 1))
+	assert.Equal(t, "", availability.Check(windows, nil, d(3), 9))
+
+	leaves := []models.Unavailability{{StartDate: d(3), EndDate: d(5), Reason: "pelatihan"}}
+	assert.Equal(t, "teacher unavailable 2024-01-03 to 2024-01-05: pelatihan", availability.Check(windows, leaves, d(3), 1))
+	assert.Equal(t, "", availability.Check(nil, leaves, d(6), 1))
+}
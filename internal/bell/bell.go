@@ -0,0 +1,55 @@
+package bell
+
+import (
+	"sort"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// FromLessons derives a bell schedule from lessons when none is configured:
+// for each jam_ke the most common start/end pair wins.
+func FromLessons(rows []models.Schedule) []models.BellPeriod {
+	times := map[int]map[[2]string]int{}
+	for _, r := range rows {
+		if _, ok := times[r.JamKe]; !ok {
+			times[r.JamKe] = map[[2]string]int{}
+		}
+		times[r.JamKe][[2]string{r.TimeStart, r.TimeEnd}]++
+	}
+
+	var out []models.BellPeriod
+	for jk, counts := range times {
+		var best [2]string
+		bestN := 0
+		for pair, n := range counts {
+			if n > bestN || (n == bestN && pair[0]+pair[1] < best[0]+best[1]) {
+				best, bestN = pair, n
+			}
+		}
+		out = append(out, models.BellPeriod{JamKe: jk, TimeStart: best[0], TimeEnd: best[1]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].JamKe < out[j].JamKe })
+	return out
+}
+
+// On returns the periods rung on the given ISO weekday, ordered by jam_ke.
+func On(periods []models.BellPeriod, weekday int) []models.BellPeriod {
+	var out []models.BellPeriod
+	for _, p := range periods {
+		if p.On(weekday) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].JamKe < out[j].JamKe })
+	return out
+}
+
+// Find returns the period jamKe rung on weekday.
+func Find(periods []models.BellPeriod, weekday, jamKe int) (models.BellPeriod, bool) {
+	for _, p := range periods {
+		if p.JamKe == jamKe && p.On(weekday) {
+			return p, true
+		}
+	}
+	return models.BellPeriod{}, false
+}
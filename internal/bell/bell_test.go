@@ -0,0 +1,41 @@
+package bell_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/bell"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestFromLessons(t *testing.T) {
+	rows := []models.Schedule{
+		{JamKe: 2, TimeStart: "07:45:00", TimeEnd: "08:30:00"},
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:30:00"},
+	}
+	assert.Equal(t, []models.BellPeriod{
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{JamKe: 2, TimeStart: "07:45:00", TimeEnd: "08:30:00"},
+	}, bell.FromLessons(rows))
+}
+
+func TestOn(t *testing.T) {
+	periods := []models.BellPeriod{
+		{JamKe: 2, TimeStart: "07:45:00", TimeEnd: "08:30:00"},
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{JamKe: 3, TimeStart: "08:30:00", TimeEnd: "09:15:00", Weekdays: []int{1, 2, 3, 4}},
+	}
+	assert.Len(t, bell.On(periods, 1), 3)
+	assert.Equal(t, 1, bell.On(periods, 1)[0].JamKe)
+	assert.Len(t, bell.On(periods, 5), 2)
+	assert.Empty(t, bell.On(periods, 7))
+
+	_, ok := bell.Find(periods, 5, 3)
+	assert.False(t, ok)
+	p, ok := bell.Find(periods, 4, 3)
+	assert.True(t, ok)
+	assert.Equal(t, "08:30:00", p.TimeStart)
+}
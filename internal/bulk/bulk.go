@@ -0,0 +1,69 @@
+// Package bulk holds the pieces shared by the bulk schedule operations: the
+// filter selecting the lessons and the confirmation token tying an
+// execution to the dry run the caller has seen.
+package bulk
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Operations.
+const (
+	OpDelete = "delete"
+	OpShift  = "shift"
+)
+
+// Filter selects the lessons of a bulk operation. The date range is
+// mandatory so that a batch can never silently cover the whole collection.
+type Filter struct {
+	Start      time.Time
+	End        time.Time
+	ClassCode  string
+	TeacherNIK string
+}
+
+// BSON returns the Mongo filter of f.
+func (f Filter) BSON() bson.M {
+	m := bson.M{"date": bson.M{"$gte": f.Start, "$lte": f.End}}
+	if f.ClassCode != "" {
+		m["class_code"] = f.ClassCode
+	}
+	if f.TeacherNIK != "" {
+		m["teacher_nik"] = f.TeacherNIK
+	}
+	return m
+}
+
+// NewKey draws a random key for Token.
+func NewKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("bulk: no randomness for the token key: %v", err))
+	}
+	return key
+}
+
+// Token fingerprints an operation together with the exact lessons it
+// matched. A dry run hands it out and the execution must present it again,
+// so the batch is refused when the matching lessons changed in between.
+// It is signed with the server's key, so a client cannot compute it and
+// skip the dry run.
+func Token(key []byte, op string, shiftDays int, f Filter, uuids []string) string {
+	sorted := append([]string(nil), uuids...)
+	sort.Strings(sorted)
+
+	h := hmac.New(sha256.New, key)
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00",
+		op, shiftDays, f.Start.Format("2006-01-02"), f.End.Format("2006-01-02"), f.ClassCode, f.TeacherNIK)
+	h.Write([]byte(strings.Join(sorted, "\x00")))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
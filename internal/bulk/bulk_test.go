@@ -0,0 +1,47 @@
+package bulk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/bulk"
+)
+
+func TestToken(t *testing.T) {
+	f := bulk.Filter{
+		Start:     time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		ClassCode: "X-1",
+	}
+	key := []byte("server key")
+	tok := bulk.Token(key, bulk.OpDelete, 0, f, []string{"a", "b"})
+
+	assert.Len(t, tok, 32)
+	// The order in which lessons were found does not matter.
+	assert.Equal(t, tok, bulk.Token(key, bulk.OpDelete, 0, f, []string{"b", "a"}))
+
+	assert.NotEqual(t, tok, bulk.Token(key, bulk.OpDelete, 0, f, []string{"a", "b", "c"}))
+	assert.NotEqual(t, tok, bulk.Token(key, bulk.OpShift, 0, f, []string{"a", "b"}))
+	assert.NotEqual(t, tok, bulk.Token(key, bulk.OpShift, 7, f, []string{"a", "b"}))
+
+	other := f
+	other.TeacherNIK = "123"
+	assert.NotEqual(t, tok, bulk.Token(key, bulk.OpDelete, 0, other, []string{"a", "b"}))
+
+	// Without the server's key the token cannot be computed.
+	assert.NotEqual(t, tok, bulk.Token(bulk.NewKey(), bulk.OpDelete, 0, f, []string{"a", "b"}))
+	assert.NotEqual(t, tok, bulk.Token(nil, bulk.OpDelete, 0, f, []string{"a", "b"}))
+}
+
+func TestFilterBSON(t *testing.T) {
+	f := bulk.Filter{Start: time.Unix(0, 0), End: time.Unix(0, 0)}
+	m := f.BSON()
+	assert.Contains(t, m, "date")
+	assert.NotContains(t, m, "class_code")
+	assert.NotContains(t, m, "teacher_nik")
+
+	f.TeacherNIK = "123"
+	assert.Equal(t, "123", f.BSON()["teacher_nik"])
+}
@@ -0,0 +1,93 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// WeekStart returns the Monday of the (school) week containing d.
+func WeekStart(d time.Time) time.Time {
+	offset := (int(d.Weekday()) + 6) % 7
+	return time.Date(d.Year(), d.Month(), d.Day()-offset, 0, 0, 0, 0, d.Location())
+}
+
+// Week is one Monday-based week clipped to a requested date range. Start
+// and End are both inclusive dates.
+type Week struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Full reports whether w covers a whole school week, Monday to Saturday.
+func (w Week) Full() bool {
+	return w.End.Sub(w.Start) >= 5*24*time.Hour
+}
+
+// Weeks splits the inclusive range [start, end] into school weeks. The
+// first and last week are clipped to the range, so a range from Wednesday
+// to the following Tuesday yields two weeks.
+func Weeks(start, end time.Time) []Week {
+	var out []Week
+	for ws := WeekStart(start); !ws.After(end); ws = ws.AddDate(0, 0, 7) {
+		w := Week{Start: ws, End: ws.AddDate(0, 0, 6)}
+		if w.Start.Before(start) {
+			w.Start = start
+		}
+		if w.End.After(end) {
+			w.End = end
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// WeekIndex returns the position of d in Weeks(start, ...), or -1 when d
+// lies before start.
+func WeekIndex(start, d time.Time) int {
+	if d.Before(start) {
+		return -1
+	}
+	return int(WeekStart(d).Sub(WeekStart(start)).Hours()/24) / 7
+}
+
+// HolidayOn returns the holiday covering d, if any.
+func HolidayOn(holidays []models.Holiday, d time.Time) (models.Holiday, bool) {
+	for _, h := range holidays {
+		if !d.Before(h.StartDate) && !d.After(h.EndDate) {
+			return h, true
+		}
+	}
+	return models.Holiday{}, false
+}
+
+// TermOn returns the term containing d, if any.
+func TermOn(terms []models.Term, d time.Time) (models.Term, bool) {
+	for _, t := range terms {
+		if !d.Before(t.StartDate) && !d.After(t.EndDate) {
+			return t, true
+		}
+	}
+	return models.Term{}, false
+}
+
+// LockedTerm returns the first locked term containing one of dates.
+func LockedTerm(terms []models.Term, dates ...time.Time) (models.Term, bool) {
+	for _, d := range dates {
+		if t, ok := TermOn(terms, d); ok && t.Locked {
+			return t, true
+		}
+	}
+	return models.Term{}, false
+}
+
+// OverlappingTerm returns a term sharing at least one day with
+// [start, end].
+func OverlappingTerm(terms []models.Term, start, end time.Time) (models.Term, bool) {
+	for _, t := range terms {
+		if !start.After(t.EndDate) && !end.Before(t.StartDate) {
+			return t, true
+		}
+	}
+	return models.Term{}, false
+}
@@ -0,0 +1,86 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func day(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestWeekStart(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	assert.Equal(t, day(1, 1), calendar.WeekStart(day(1, 1)))
+	assert.Equal(t, day(1, 1), calendar.WeekStart(day(1, 6)))
+	assert.Equal(t, day(1, 1), calendar.WeekStart(day(1, 7)))
+	assert.Equal(t, day(1, 8), calendar.WeekStart(day(1, 8)))
+}
+
+func TestWeeksAcrossMonths(t *testing.T) {
+	// Wednesday 2024-01-24 to Tuesday 2024-02-06.
+	weeks := calendar.Weeks(day(1, 24), day(2, 6))
+	assert.Equal(t, []calendar.Week{
+		{Start: day(1, 24), End: day(1, 28)},
+		{Start: day(1, 29), End: day(2, 4)},
+		{Start: day(2, 5), End: day(2, 6)},
+	}, weeks)
+	assert.False(t, weeks[0].Full())
+	assert.True(t, weeks[1].Full())
+	assert.False(t, weeks[2].Full())
+
+	assert.Equal(t, 0, calendar.WeekIndex(day(1, 24), day(1, 28)))
+	assert.Equal(t, 1, calendar.WeekIndex(day(1, 24), day(2, 1)))
+	assert.Equal(t, 2, calendar.WeekIndex(day(1, 24), day(2, 6)))
+	assert.Equal(t, -1, calendar.WeekIndex(day(1, 24), day(1, 23)))
+}
+
+func TestHolidayOn(t *testing.T) {
+	holidays := []models.Holiday{
+		{Name: "Tahun Baru", StartDate: day(1, 1), EndDate: day(1, 1)},
+		{Name: "Libur Semester", StartDate: day(6, 24), EndDate: day(7, 12)},
+	}
+
+	h, ok := calendar.HolidayOn(holidays, day(1, 1))
+	assert.True(t, ok)
+	assert.Equal(t, "Tahun Baru", h.Name)
+
+	h, ok = calendar.HolidayOn(holidays, day(7, 12))
+	assert.True(t, ok)
+	assert.Equal(t, "Libur Semester", h.Name)
+
+	_, ok = calendar.HolidayOn(holidays, day(1, 2))
+	assert.False(t, ok)
+	_, ok = calendar.HolidayOn(nil, day(1, 1))
+	assert.False(t, ok)
+}
+
+func TestTerms(t *testing.T) {
+	terms := []models.Term{
+		{Name: "Ganjil", StartDate: day(1, 8), EndDate: day(6, 20), Locked: true},
+		{Name: "Genap", StartDate: day(7, 15), EndDate: day(12, 20)},
+	}
+
+	term, ok := calendar.TermOn(terms, day(6, 20))
+	assert.True(t, ok)
+	assert.Equal(t, "Ganjil", term.Name)
+	_, ok = calendar.TermOn(terms, day(7, 1))
+	assert.False(t, ok)
+
+	_, ok = calendar.LockedTerm(terms, day(7, 20), day(7, 1))
+	assert.False(t, ok)
+	term, ok = calendar.LockedTerm(terms, day(7, 20), day(3, 1))
+	assert.True(t, ok)
+	assert.Equal(t, "Ganjil", term.Name)
+
+	term, ok = calendar.OverlappingTerm(terms, day(6, 21), day(7, 15))
+	assert.True(t, ok)
+	assert.Equal(t, "Genap", term.Name)
+	_, ok = calendar.OverlappingTerm(terms, day(6, 21), day(7, 14))
+	assert.False(t, ok)
+}
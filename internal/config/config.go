@@ -2,13 +2,68 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 type Config struct {
+	// MongoURI should name a replica set (one node will do): swaps, bulk
+	// shifts and draft publishing need transactions, see repo.Transactions.
 	MongoURI string
 	DBName   string
 	APIKey   string
 	Port     string
+
+	SchoolName    string
+	SchoolAddress string
+	// SchoolTimezone is an IANA zone such as Asia/Jakarta (WIB),
+	// Asia/Makassar (WITA) or Asia/Jayapura (WIT).
+	SchoolTimezone string
+
+	// JPMinutes is the length of one JP (jam pelajaran) in minutes and
+	// JPRounding how a lesson's duration is rounded to JP, see package jp.
+	// An unknown JPRounding is reported at startup and replaced by nearest.
+	JPMinutes  int
+	JPRounding string
+
+	// Default workload limits in JP (zero disables a limit) and whether
+	// exceeding a maximum only warns ("warn") or rejects the change
+	// ("block").
+	WorkloadMinWeeklyJP float64
+	WorkloadMaxWeeklyJP float64
+	WorkloadMaxDailyJP  float64
+	WorkloadMode        string
+
+	// NIKPattern is the regular expression teacher NIKs must match.
+	NIKPattern string
+
+	// EventsChangeStream feeds the event bus from a MongoDB change stream
+	// instead of the handlers, so writes by other processes are seen too.
+	// It needs a replica set; see repo.Watch.
+	EventsChangeStream bool
+
+	// Webhook deliveries are tried WebhookMaxAttempts times, waiting
+	// WebhookBackoffSeconds after the first failure and twice as long
+	// after each further one, at most WebhookBackoffMaxSeconds.
+	WebhookMaxAttempts       int
+	WebhookBackoffSeconds    int
+	WebhookBackoffMaxSeconds int
+
+	// Teacher notifications go through SMTP when NotifySMTPAddr is set
+	// and otherwise into .eml files in NotifyDir, or the log. Change
+	// notices cover lessons within NotifyHorizonDays; the digest of the
+	// next day's lessons goes out at NotifyDigestHour, school time.
+	NotifySMTPAddr     string
+	NotifySMTPUser     string
+	NotifySMTPPassword string
+	NotifyFrom         string
+	NotifyDir          string
+	NotifyHorizonDays  int
+	NotifyDigestHour   int
+
+	// BulkTokenKey signs the confirm tokens of bulk dry runs. Without it
+	// a random key is drawn at startup, so tokens do not survive a
+	// restart and are not accepted by other instances.
+	BulkTokenKey string
 }
 
 func LoadConfig() Config {
@@ -17,5 +72,64 @@ func LoadConfig() Config {
 		DBName:   os.Getenv("egs"),
 		APIKey:   os.Getenv("SECRET123"),
 		Port:     os.Getenv("PORT"), 
+
+		SchoolName:    os.Getenv("SCHOOL_NAME"),
+		SchoolAddress: os.Getenv("SCHOOL_ADDRESS"),
+
+		SchoolTimezone: os.Getenv("SCHOOL_TZ"),
+
+		JPMinutes:  envInt("JP_MINUTES", 45),
+		JPRounding: os.Getenv("JP_ROUNDING"),
+
+		WorkloadMinWeeklyJP: envFloat("WORKLOAD_MIN_WEEKLY_JP", 24),
+		WorkloadMaxWeeklyJP: envFloat("WORKLOAD_MAX_WEEKLY_JP", 40),
+		WorkloadMaxDailyJP:  envFloat("WORKLOAD_MAX_DAILY_JP", 0),
+		WorkloadMode:        os.Getenv("WORKLOAD_MODE"),
+
+		NIKPattern: os.Getenv("NIK_PATTERN"),
+
+		EventsChangeStream: envBool("EVENTS_CHANGE_STREAM"),
+
+		WebhookMaxAttempts:       envInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBackoffSeconds:    envInt("WEBHOOK_BACKOFF_SECONDS", 30),
+		WebhookBackoffMaxSeconds: envInt("WEBHOOK_BACKOFF_MAX_SECONDS", 3600),
+
+		NotifySMTPAddr:     os.Getenv("NOTIFY_SMTP_ADDR"),
+		NotifySMTPUser:     os.Getenv("NOTIFY_SMTP_USER"),
+		NotifySMTPPassword: os.Getenv("NOTIFY_SMTP_PASSWORD"),
+		NotifyFrom:         envString("NOTIFY_FROM", "jadwal@localhost"),
+		NotifyDir:          os.Getenv("NOTIFY_DIR"),
+		NotifyHorizonDays:  envInt("NOTIFY_HORIZON_DAYS", 3),
+		NotifyDigestHour:   envInt("NOTIFY_DIGEST_HOUR", 18),
+
+		BulkTokenKey: os.Getenv("BULK_TOKEN_KEY"),
+	}
+}
+
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+func envFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
+
+func envBool(key string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(key))
+	return v
+}
+
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
+	return def
 }
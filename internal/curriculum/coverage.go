@@ -0,0 +1,152 @@
+package curriculum
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Coverage statuses.
+const (
+	StatusOK             = "ok"
+	StatusUnderScheduled = "under_scheduled"
+	StatusOverScheduled  = "over_scheduled"
+	StatusNoRequirement  = "no_requirement"
+)
+
+// Line compares one class/subject requirement with the lessons of a range.
+type Line struct {
+	ClassCode     string  `json:"class_code"`
+	SubjectCode   string  `json:"subject_code"`
+	RequiredJP    float64 `json:"required_jp"`
+	ScheduledJP   float64 `json:"scheduled_jp"`
+	DeliveredJP   float64 `json:"delivered_jp"`
+	ScheduleGapJP float64 `json:"schedule_gap_jp"`
+	DeliveryGapJP float64 `json:"delivery_gap_jp"`
+	// WeeksShort lists the weeks (by start date) in which fewer JP than
+	// JPPerWeek were scheduled.
+	WeeksShort []string `json:"weeks_short"`
+	// PartialWeeks lists the weeks (by start date) the range cuts short of
+	// Monday to Saturday. A weekly requirement is not checked in them, and
+	// their lessons are left out of the JP figures.
+	PartialWeeks []string `json:"partial_weeks"`
+	Status       string   `json:"status"`
+}
+
+type key struct{ class, subject string }
+
+// Coverage compares reqs with rows over the inclusive range [start, end].
+// The weekly requirement applies to every full school week of the range;
+// weeks the range cuts short are reported as partial and not checked. A
+// requirement without a weekly figure treats the range as the semester.
+// Lessons count as delivered when journal records them as taught.
+func Coverage(reqs []models.CurriculumRequirement, rows []models.Schedule, calc jp.Calculator, start, end time.Time, journal models.Journal) []Line {
+	weeks := calendar.Weeks(start, end)
+	type agg struct {
+		scheduled, delivered      float64
+		perWeek, deliveredPerWeek []float64
+	}
+	data := map[key]*agg{}
+	get := func(k key) *agg {
+		if a, ok := data[k]; ok {
+			return a
+		}
+		a := &agg{perWeek: make([]float64, len(weeks)), deliveredPerWeek: make([]float64, len(weeks))}
+		data[k] = a
+		return a
+	}
+
+	for _, r := range rows {
+		i := calendar.WeekIndex(start, r.Date)
+		if i < 0 || i >= len(weeks) {
+			continue
+		}
+		a := get(key{r.ClassCode, r.SubjectCode})
+		v := calc.Of(r)
+		a.scheduled += v
+		a.perWeek[i] += v
+		if journal.Delivered(r) {
+			a.delivered += v
+			a.deliveredPerWeek[i] += v
+		}
+	}
+
+	var out []Line
+	required := map[key]bool{}
+	for _, req := range reqs {
+		k := key{req.ClassCode, req.SubjectCode}
+		required[k] = true
+		a := get(k)
+
+		l := Line{
+			ClassCode:    req.ClassCode,
+			SubjectCode:  req.SubjectCode,
+			ScheduledJP:  a.scheduled,
+			DeliveredJP:  a.delivered,
+			WeeksShort:   []string{},
+			PartialWeeks: []string{},
+		}
+		if req.JPPerWeek > 0 {
+			l.ScheduledJP, l.DeliveredJP = 0, 0
+			for i, w := range weeks {
+				if !w.Full() {
+					l.PartialWeeks = append(l.PartialWeeks, w.Start.Format("2006-01-02"))
+					continue
+				}
+				l.RequiredJP += req.JPPerWeek
+				l.ScheduledJP += a.perWeek[i]
+				l.DeliveredJP += a.deliveredPerWeek[i]
+				if a.perWeek[i] < req.JPPerWeek {
+					l.WeeksShort = append(l.WeeksShort, w.Start.Format("2006-01-02"))
+				}
+			}
+		} else {
+			l.RequiredJP = req.JPPerSemester
+		}
+		if gap := l.RequiredJP - l.ScheduledJP; gap > 0 {
+			l.ScheduleGapJP = gap
+		}
+		if gap := l.RequiredJP - l.DeliveredJP; gap > 0 {
+			l.DeliveryGapJP = gap
+		}
+		switch {
+		case l.ScheduledJP < l.RequiredJP || len(l.WeeksShort) > 0:
+			l.Status = StatusUnderScheduled
+		case l.ScheduledJP > l.RequiredJP:
+			l.Status = StatusOverScheduled
+		default:
+			l.Status = StatusOK
+		}
+		out = append(out, l)
+	}
+
+	// Lessons of subjects the class has no requirement for.
+	for k, a := range data {
+		if required[k] || a.scheduled == 0 {
+			continue
+		}
+		out = append(out, Line{
+			ClassCode:    k.class,
+			SubjectCode:  k.subject,
+			ScheduledJP:  a.scheduled,
+			DeliveredJP:  a.delivered,
+			WeeksShort:   []string{},
+			PartialWeeks: []string{},
+			Status:       StatusNoRequirement,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ClassCode != out[j].ClassCode {
+			return out[i].ClassCode < out[j].ClassCode
+		}
+		return out[i].SubjectCode < out[j].SubjectCode
+	})
+	if out == nil {
+		out = []Line{}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package curriculum_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/curriculum"
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestCoverage(t *testing.T) {
+	calc := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	// 2024-01-01 is a Monday.
+	d := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+	lesson := func(class, subject string, day int) models.Schedule {
+		return models.Schedule{UUID: class + subject + d(day).Format("02"), ClassCode: class, SubjectCode: subject, Date: d(day), TimeStart: "07:00:00", TimeEnd: "08:30:00"}
+	}
+
+	reqs := []models.CurriculumRequirement{
+		{ClassCode: "X1", SubjectCode: "MTK", JPPerWeek: 4},
+		{ClassCode: "X1", SubjectCode: "BIN", JPPerWeek: 2},
+	}
+	rows := []models.Schedule{
+		lesson("X1", "MTK", 1), lesson("X1", "MTK", 3),
+		lesson("X1", "MTK", 8),
+		lesson("X1", "BIN", 2), lesson("X1", "BIN", 9),
+		lesson("X1", "OR", 4),
+	}
+
+	// Of the first week, the MTK lesson of the 3rd was cancelled.
+	journal := models.NewJournal([]models.JournalEntry{
+		{ScheduleUUID: "X1MTK01", Status: models.JournalDelivered},
+		{ScheduleUUID: "X1MTK03", Status: models.JournalCancelled},
+		{ScheduleUUID: "X1BIN02", Status: models.JournalSubstituted, SubstituteNIK: "9"},
+		{ScheduleUUID: "X1OR04", Status: models.JournalDelivered},
+	})
+
+	lines := curriculum.Coverage(reqs, rows, calc, d(1), d(14), journal)
+	assert.Len(t, lines, 3)
+
+	bin := lines[0]
+	assert.Equal(t, "BIN", bin.SubjectCode)
+	assert.Equal(t, curriculum.StatusOK, bin.Status)
+	assert.Equal(t, 4.0, bin.RequiredJP)
+	assert.Equal(t, 2.0, bin.DeliveredJP)
+	assert.Equal(t, 2.0, bin.DeliveryGapJP)
+
+	mtk := lines[1]
+	assert.Equal(t, curriculum.StatusUnderScheduled, mtk.Status)
+	assert.Equal(t, 8.0, mtk.RequiredJP)
+	assert.Equal(t, 6.0, mtk.ScheduledJP)
+	assert.Equal(t, 2.0, mtk.ScheduleGapJP)
+	assert.Equal(t, 2.0, mtk.DeliveredJP)
+	assert.Equal(t, []string{"2024-01-08"}, mtk.WeeksShort)
+
+	assert.Equal(t, curriculum.StatusNoRequirement, lines[2].Status)
+}
+
+func TestCoveragePartialWeeks(t *testing.T) {
+	calc := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	d := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+	lesson := func(day int) models.Schedule {
+		return models.Schedule{UUID: d(day).Format("02"), ClassCode: "X1", SubjectCode: "MTK", Date: d(day), TimeStart: "07:00:00", TimeEnd: "08:30:00"}
+	}
+	reqs := []models.CurriculumRequirement{{ClassCode: "X1", SubjectCode: "MTK", JPPerWeek: 4}}
+	// Wednesday 3rd to Tuesday 16th: only the week of the 8th is whole.
+	rows := []models.Schedule{lesson(3), lesson(8), lesson(10), lesson(15)}
+
+	lines := curriculum.Coverage(reqs, rows, calc, d(3), d(16), models.Journal{})
+	assert.Len(t, lines, 1)
+	mtk := lines[0]
+	assert.Equal(t, curriculum.StatusOK, mtk.Status)
+	assert.Equal(t, 4.0, mtk.RequiredJP)
+	assert.Equal(t, 4.0, mtk.ScheduledJP)
+	assert.Empty(t, mtk.WeeksShort)
+	assert.Equal(t, []string{"2024-01-03", "2024-01-15"}, mtk.PartialWeeks)
+}
@@ -0,0 +1,117 @@
+// Package draft compares the lessons of a draft with the published
+// timetable and finds the drafts covering a date range.
+package draft
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Change is a lesson present in both versions with different fields.
+type Change struct {
+	UUID   string          `json:"uuid"`
+	Fields []string        `json:"fields"`
+	Before models.Schedule `json:"before"`
+	After  models.Schedule `json:"after"`
+}
+
+// Diff lists what publishing a draft would change. Lessons are matched by
+// UUID; a draft keeps the UUIDs of the lessons it copied.
+type Diff struct {
+	Added   []models.Schedule `json:"added"`
+	Removed []models.Schedule `json:"removed"`
+	Changed []Change          `json:"changed"`
+}
+
+// Empty reports whether publishing would change nothing.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare diffs the draft lessons against the published ones.
+func Compare(published, drafted []models.Schedule) Diff {
+	d := Diff{Added: []models.Schedule{}, Removed: []models.Schedule{}, Changed: []Change{}}
+	before := make(map[string]models.Schedule, len(published))
+	for _, s := range published {
+		before[s.UUID] = s
+	}
+	seen := map[string]bool{}
+	for _, s := range drafted {
+		seen[s.UUID] = true
+		old, ok := before[s.UUID]
+		if !ok {
+			d.Added = append(d.Added, s)
+			continue
+		}
+		if fields := ChangedFields(old, s); len(fields) > 0 {
+			d.Changed = append(d.Changed, Change{UUID: s.UUID, Fields: fields, Before: old, After: s})
+		}
+	}
+	for _, s := range published {
+		if !seen[s.UUID] {
+			d.Removed = append(d.Removed, s)
+		}
+	}
+	sortLessons(d.Added)
+	sortLessons(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool { return less(d.Changed[i].After, d.Changed[j].After) })
+	return d
+}
+
+// ChangedFields names the JSON fields of a lesson that differ between a
+// and b. Bookkeeping such as timestamps and IDs is ignored.
+func ChangedFields(a, b models.Schedule) []string {
+	var out []string
+	add := func(name string, differ bool) {
+		if differ {
+			out = append(out, name)
+		}
+	}
+	add("class_code", a.ClassCode != b.ClassCode)
+	add("class_name", a.ClassName != b.ClassName)
+	add("subject_code", a.SubjectCode != b.SubjectCode)
+	add("teacher_nik", a.TeacherNIK != b.TeacherNIK)
+	add("teacher_name", a.TeacherName != b.TeacherName)
+	add("date", !a.Date.Equal(b.Date))
+	add("jam_ke", a.JamKe != b.JamKe)
+	add("time_start", a.TimeStart != b.TimeStart)
+	add("time_end", a.TimeEnd != b.TimeEnd)
+	add("lesson_type", a.LessonType != b.LessonType)
+	return out
+}
+
+func less(a, b models.Schedule) bool {
+	if !a.Date.Equal(b.Date) {
+		return a.Date.Before(b.Date)
+	}
+	if a.JamKe != b.JamKe {
+		return a.JamKe < b.JamKe
+	}
+	return a.ClassCode < b.ClassCode
+}
+
+func sortLessons(rows []models.Schedule) {
+	sort.Slice(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+}
+
+// Overlapping returns a draft sharing at least one day with [start, end].
+func Overlapping(drafts []models.Draft, start, end time.Time) (models.Draft, bool) {
+	for _, d := range drafts {
+		if !start.After(d.EndDate) && !end.Before(d.StartDate) {
+			return d, true
+		}
+	}
+	return models.Draft{}, false
+}
+
+// Covering returns the first draft whose range contains one of dates.
+func Covering(drafts []models.Draft, dates ...time.Time) (models.Draft, bool) {
+	for _, t := range dates {
+		if d, ok := Overlapping(drafts, t, t); ok {
+			return d, true
+		}
+	}
+	return models.Draft{}, false
+}
@@ -0,0 +1,68 @@
+package draft_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/draft"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func lesson(uuid string, day, jamKe int) models.Schedule {
+	return models.Schedule{
+		UUID:       uuid,
+		ClassCode:  "X-1",
+		TeacherNIK: "123",
+		Date:       time.Date(2024, 8, day, 0, 0, 0, 0, time.UTC),
+		JamKe:      jamKe,
+		TimeStart:  "07:00",
+		TimeEnd:    "07:45",
+		UpdatedAt:  time.Now(),
+	}
+}
+
+func TestCompare(t *testing.T) {
+	published := []models.Schedule{lesson("a", 5, 1), lesson("b", 5, 2), lesson("c", 6, 1)}
+
+	moved := lesson("b", 6, 3)
+	moved.TimeStart, moved.TimeEnd = "08:30", "09:15"
+	touched := lesson("c", 6, 1)
+	touched.UpdatedAt = touched.UpdatedAt.Add(time.Hour)
+	drafted := []models.Schedule{lesson("d", 7, 1), moved, touched}
+
+	d := draft.Compare(published, drafted)
+
+	assert.False(t, d.Empty())
+	assert.Len(t, d.Added, 1)
+	assert.Equal(t, "d", d.Added[0].UUID)
+	assert.Len(t, d.Removed, 1)
+	assert.Equal(t, "a", d.Removed[0].UUID)
+	if assert.Len(t, d.Changed, 1) {
+		assert.Equal(t, "b", d.Changed[0].UUID)
+		assert.Equal(t, []string{"date", "jam_ke", "time_start", "time_end"}, d.Changed[0].Fields)
+	}
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	rows := []models.Schedule{lesson("a", 5, 1)}
+	assert.True(t, draft.Compare(rows, rows).Empty())
+	assert.True(t, draft.Compare(nil, nil).Empty())
+}
+
+func TestOverlappingAndCovering(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	drafts := []models.Draft{{Name: "week 2", StartDate: day(12), EndDate: day(16)}}
+
+	_, ok := draft.Overlapping(drafts, day(5), day(11))
+	assert.False(t, ok)
+	d, ok := draft.Overlapping(drafts, day(5), day(12))
+	assert.True(t, ok)
+	assert.Equal(t, "week 2", d.Name)
+
+	_, ok = draft.Covering(drafts, day(9), day(17))
+	assert.False(t, ok)
+	_, ok = draft.Covering(drafts, day(9), day(16))
+	assert.True(t, ok)
+}
@@ -0,0 +1,210 @@
+// Package events is the in-process bus schedule changes are published on.
+// Handlers (or a MongoDB change stream, see repo.Watch) publish; the SSE
+// stream and other listeners subscribe with a filter.
+package events
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Event types.
+const (
+	Created = "schedule.created"
+	Updated = "schedule.updated"
+	Deleted = "schedule.deleted"
+)
+
+// Event is one change to one published lesson.
+type Event struct {
+	// ID numbers the events of this process in publish order.
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	SchoolID string `json:"-"`
+	UUID     string `json:"uuid"`
+	// Schedule is the lesson after the change, or as it was before a
+	// delete.
+	Schedule *models.Schedule `json:"schedule,omitempty"`
+	// Previous is the lesson before an update, when known.
+	Previous *models.Schedule `json:"previous,omitempty"`
+	At       time.Time        `json:"at"`
+}
+
+// Filter selects the events of one school, optionally of one class or
+// teacher. An update matches when either its old or its new state does,
+// so a lesson moved to another teacher reaches both teachers. An empty
+// School matches every school, for listeners inside the server such as
+// the webhook dispatcher.
+type Filter struct {
+	School     string
+	ClassCode  string
+	TeacherNIK string
+}
+
+func (f Filter) Match(e Event) bool {
+	if f.School != "" && e.SchoolID != f.School {
+		return false
+	}
+	return f.lesson(e.Schedule) || (e.Previous != nil && f.lesson(e.Previous))
+}
+
+func (f Filter) lesson(s *models.Schedule) bool {
+	if s == nil {
+		return f.ClassCode == "" && f.TeacherNIK == ""
+	}
+	return (f.ClassCode == "" || s.ClassCode == f.ClassCode) &&
+		(f.TeacherNIK == "" || s.TeacherNIK == f.TeacherNIK)
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+	// queue is set for subscribers from Queue, which never miss events.
+	queue   *queue
+	dropped uint64
+}
+
+// Bus fans events out to subscribers. Publish never blocks: a Subscribe
+// subscriber whose buffer is full misses the event, which is counted and
+// logged; a Queue subscriber has its events queued however far behind it
+// is.
+type Bus struct {
+	mu      sync.Mutex
+	seq     uint64
+	dropped uint64
+	subs    map[*subscriber]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: map[*subscriber]struct{}{}}
+}
+
+// Publish numbers e, stamps it if needed and hands it to every matching
+// subscriber.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e.ID = strconv.FormatUint(b.seq, 10)
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		if s.queue != nil {
+			s.queue.push(e)
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped++
+			b.dropped++
+			if s.dropped == 1 || s.dropped%100 == 0 {
+				log.Printf("events: subscriber %+v is behind, %d events dropped", s.filter, s.dropped)
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel of the events matching f, buffered to hold
+// buffer events, and a func that ends the subscription and closes the
+// channel. It suits listeners that can miss events, such as SSE clients.
+func (b *Bus) Subscribe(f Filter, buffer int) (<-chan Event, func()) {
+	s := &subscriber{filter: f, ch: make(chan Event, buffer)}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
+
+// Queue is Subscribe for listeners that must see every event, such as the
+// webhook dispatcher: events wait in an unbounded queue until read, so a
+// burst (an import, a bulk shift) is never dropped. Events still queued
+// when the subscription ends are discarded.
+func (b *Bus) Queue(f Filter) (<-chan Event, func()) {
+	q := &queue{more: make(chan struct{}, 1), done: make(chan struct{})}
+	s := &subscriber{filter: f, ch: make(chan Event), queue: q}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	go q.pump(s.ch)
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.mu.Unlock()
+			close(q.done)
+		})
+	}
+}
+
+// Dropped returns how many events Subscribe subscribers have missed.
+func (b *Bus) Dropped() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+type queue struct {
+	mu     sync.Mutex
+	events []Event
+	more   chan struct{}
+	done   chan struct{}
+}
+
+func (q *queue) push(e Event) {
+	q.mu.Lock()
+	q.events = append(q.events, e)
+	q.mu.Unlock()
+	select {
+	case q.more <- struct{}{}:
+	default:
+	}
+}
+
+// pump hands the queued events to out in order until the subscription
+// ends, then closes out.
+func (q *queue) pump(out chan<- Event) {
+	defer close(out)
+	for {
+		q.mu.Lock()
+		pending := q.events
+		q.events = nil
+		q.mu.Unlock()
+		for _, e := range pending {
+			select {
+			case out <- e:
+			case <-q.done:
+				return
+			}
+		}
+		select {
+		case <-q.more:
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
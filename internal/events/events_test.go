@@ -0,0 +1,75 @@
+package events
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestFilterMatch(t *testing.T) {
+	lesson := &models.Schedule{ClassCode: "X-1", TeacherNIK: "111"}
+	moved := &models.Schedule{ClassCode: "X-1", TeacherNIK: "222"}
+	e := Event{Type: Updated, SchoolID: "a", Schedule: moved, Previous: lesson}
+
+	assert.True(t, Filter{School: "a"}.Match(e))
+	assert.False(t, Filter{School: "b"}.Match(e))
+	assert.True(t, Filter{}.Match(e))
+	assert.True(t, Filter{School: "a", ClassCode: "X-1"}.Match(e))
+	assert.False(t, Filter{School: "a", ClassCode: "X-2"}.Match(e))
+	assert.True(t, Filter{School: "a", TeacherNIK: "111"}.Match(e), "old teacher")
+	assert.True(t, Filter{School: "a", TeacherNIK: "222"}.Match(e), "new teacher")
+	assert.False(t, Filter{School: "a", ClassCode: "X-1", TeacherNIK: "333"}.Match(e))
+}
+
+func TestBus(t *testing.T) {
+	b := NewBus()
+	all, stopAll := b.Subscribe(Filter{School: "a"}, 1)
+	class, stopClass := b.Subscribe(Filter{School: "a", ClassCode: "X-2"}, 4)
+	assert.Equal(t, 2, b.Subscribers())
+
+	b.Publish(Event{Type: Created, SchoolID: "a", UUID: "1", Schedule: &models.Schedule{ClassCode: "X-1"}})
+	b.Publish(Event{Type: Created, SchoolID: "a", UUID: "2", Schedule: &models.Schedule{ClassCode: "X-2"}})
+
+	e := <-all
+	assert.Equal(t, "1", e.ID)
+	assert.False(t, e.At.IsZero())
+	assert.Empty(t, all, "the second event did not fit the buffer")
+	assert.Equal(t, uint64(1), b.Dropped())
+
+	e = <-class
+	assert.Equal(t, "2", e.UUID)
+	assert.Equal(t, "2", e.ID)
+
+	stopClass()
+	stopClass()
+	_, open := <-class
+	assert.False(t, open)
+	stopAll()
+	require.Zero(t, b.Subscribers())
+	b.Publish(Event{Type: Deleted, SchoolID: "a"})
+}
+
+func TestQueueKeepsBursts(t *testing.T) {
+	b := NewBus()
+	ch, stop := b.Queue(Filter{School: "a"})
+	for i := 0; i < 1000; i++ {
+		b.Publish(Event{Type: Created, SchoolID: "a"})
+	}
+	b.Publish(Event{Type: Created, SchoolID: "b"})
+
+	for i := 1; i <= 1000; i++ {
+		e := <-ch
+		require.Equal(t, strconv.Itoa(i), e.ID)
+	}
+	assert.Zero(t, b.Dropped())
+
+	stop()
+	stop()
+	_, open := <-ch
+	assert.False(t, open)
+	assert.Zero(t, b.Subscribers())
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/attendance"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/report"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+type attendanceInput struct {
+	TeacherNIK string                  `json:"teacher_nik" binding:"required"`
+	Records    []attendanceRecordInput `json:"records" binding:"required"`
+}
+
+type attendanceRecordInput struct {
+	StudentNIS  string `json:"student_nis"`
+	StudentName string `json:"student_name"`
+	Status      string `json:"status"`
+	Note        string `json:"note"`
+}
+
+// records checks in and turns it into the attendance of lesson s.
+func (in attendanceInput) records(s *models.Schedule) ([]models.Attendance, error) {
+	var errs validate.Errors
+	if len(in.Records) == 0 {
+		errs.Add("records", validate.CodeRequired, "at least one student is required")
+	}
+	seen := map[string]bool{}
+	out := make([]models.Attendance, 0, len(in.Records))
+	for i, r := range in.Records {
+		field := fmt.Sprintf("records[%d]", i)
+		switch {
+		case r.StudentNIS == "":
+			errs.Add(field+".student_nis", validate.CodeRequired, "is required")
+		case seen[r.StudentNIS]:
+			errs.Add(field+".student_nis", validate.CodeRange, "student %s is listed twice", r.StudentNIS)
+		}
+		seen[r.StudentNIS] = true
+		if !models.ValidAttendance(r.Status) {
+			errs.Add(field+".status", validate.CodeUnknown, "must be hadir, izin, sakit or alpa, got %q", r.Status)
+		}
+		out = append(out, models.Attendance{
+			ScheduleUUID: s.UUID,
+			ClassCode:    s.ClassCode,
+			SubjectCode:  s.SubjectCode,
+			Date:         s.Date,
+			TermID:       s.TermID,
+			StudentNIS:   r.StudentNIS,
+			StudentName:  r.StudentName,
+			Status:       r.Status,
+			Note:         r.Note,
+			TeacherNIK:   in.TeacherNIK,
+		})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return out, nil
+}
+
+// SubmitAttendance records the attendance of a lesson by its teacher.
+// Students already recorded for the lesson are overwritten. The lesson
+// must have started and its term must not be locked.
+func (h *Handler) SubmitAttendance(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in attendanceInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	s, ok := h.findForChange(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+	if s.TeacherNIK != in.TeacherNIK {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden,
+			fmt.Sprintf("schedule %s is not taught by %s", s.UUID, in.TeacherNIK), nil)
+		return
+	}
+	if !s.StartAt.IsZero() && s.StartAt.After(time.Now()) {
+		invalid(c, validate.Errors{{Field: "schedule_uuid", Code: validate.CodeRange, Message: "the lesson has not started yet"}})
+		return
+	}
+	if err := h.unlocked(s.Date); err != nil {
+		fail(c, err)
+		return
+	}
+	records, err := in.records(s)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.Attendance.Save(records); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedule_uuid": s.UUID, "saved": len(records), "summary": attendance.Total(s.UUID, records)})
+}
+
+// LessonAttendance lists the attendance recorded for a lesson.
+func (h *Handler) LessonAttendance(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Attendance.Find(bson.M{"schedule_uuid": c.Param("uuid")})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"records": rows, "summary": attendance.Total(c.Param("uuid"), rows)})
+}
+
+// ClassAttendance summarizes attendance over a term or date range: per
+// student of ?class_code=, or per class when no class is given.
+// ?format=xlsx downloads the summary as a sheet.
+func (h *Handler) ClassAttendance(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	format, ok := attendanceFormat(c)
+	if !ok {
+		return
+	}
+	start, end, filter, ok := h.periodFilter(c)
+	if !ok {
+		return
+	}
+	classCode := c.Query("class_code")
+	if classCode != "" {
+		filter["class_code"] = classCode
+	}
+	rows, err := h.Attendance.Find(filter)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	key, label, groups := "class_code", "kelas", attendance.ByClass(rows)
+	if classCode != "" {
+		key, label, groups = "student_nis", "kelas_"+classCode, attendance.ByStudent(rows)
+	}
+	total := attendance.Total("total", rows)
+	if format == "xlsx" {
+		writeAttendanceXLSX(c, fmt.Sprintf("absensi_%s_%s_%s", label, start.Format("20060102"), end.Format("20060102")),
+			attendance.Table(key, groups, total))
+		return
+	}
+	resp := gin.H{"start_date": start, "end_date": end, "lessons": attendance.Lessons(rows), "total": total}
+	if classCode != "" {
+		resp["class_code"], resp["students"] = classCode, groups
+	} else {
+		resp["classes"] = groups
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// StudentAttendance summarizes one ?student_nis= per subject over a term
+// or date range. ?format=xlsx downloads the summary as a sheet.
+func (h *Handler) StudentAttendance(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	nis := c.Query("student_nis")
+	if nis == "" {
+		badRequest(c, "student_nis required")
+		return
+	}
+	format, ok := attendanceFormat(c)
+	if !ok {
+		return
+	}
+	start, end, filter, ok := h.periodFilter(c)
+	if !ok {
+		return
+	}
+	filter["student_nis"] = nis
+	rows, err := h.Attendance.Find(filter)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	subjects := attendance.BySubject(rows)
+	total := attendance.Total("total", rows)
+	if format == "xlsx" {
+		writeAttendanceXLSX(c, fmt.Sprintf("absensi_siswa_%s_%s_%s", nis, start.Format("20060102"), end.Format("20060102")),
+			attendance.Table("subject_code", subjects, total))
+		return
+	}
+	name := ""
+	if len(rows) > 0 {
+		name = rows[len(rows)-1].StudentName
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"student_nis":  nis,
+		"student_name": name,
+		"start_date":   start,
+		"end_date":     end,
+		"subjects":     subjects,
+		"total":        total,
+	})
+}
+
+func attendanceFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "xlsx" {
+		badRequest(c, "format must be json or xlsx")
+		return "", false
+	}
+	return format, true
+}
+
+func writeAttendanceXLSX(c *gin.Context, filename string, t report.Table) {
+	var buf bytes.Buffer
+	if err := report.WriteXLSX(&buf, "Absensi", t); err != nil {
+		fail(c, err)
+		return
+	}
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+	c.Writer.Write(buf.Bytes())
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func TestAttendanceInputRecords(t *testing.T) {
+	lesson := &models.Schedule{UUID: "u1", ClassCode: "X-1", SubjectCode: "MTK", TeacherNIK: "111"}
+	in := attendanceInput{TeacherNIK: "111", Records: []attendanceRecordInput{
+		{StudentNIS: "001", StudentName: "Ani", Status: models.AttendanceHadir},
+		{StudentNIS: "002", Status: models.AttendanceSakit, Note: "surat dokter"},
+	}}
+	records, err := in.records(lesson)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "X-1", records[1].ClassCode)
+	assert.Equal(t, "MTK", records[1].SubjectCode)
+	assert.Equal(t, "111", records[1].TeacherNIK)
+
+	in.Records[1].StudentNIS, in.Records[1].Status = "001", "bolos"
+	_, err = in.records(lesson)
+	var fields validate.Errors
+	require.True(t, errors.As(err, &fields))
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "records[1].student_nis", fields[0].Field)
+	assert.Equal(t, "records[1].status", fields[1].Field)
+
+	in.Records = nil
+	_, err = in.records(lesson)
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestSubmitAttendance(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/schedules", h.Create)
+	r.GET("/schedules/:uuid/attendance", h.LessonAttendance)
+	r.PUT("/schedules/:uuid/attendance", h.SubmitAttendance)
+
+	nik := newNIK()
+	past := createLesson(t, r, nik, "2024-03-04", "07:00:00", "08:30:00")
+	future := createLesson(t, r, nik, "2031-03-03", "07:00:00", "08:30:00")
+	submit := func(lesson, teacher string) int {
+		body := `{"teacher_nik": "` + teacher + `", "records": [
+			{"student_nis": "1001", "student_name": "Ani", "status": "hadir"},
+			{"student_nis": "1002", "student_name": "Dodi", "status": "sakit"}]}`
+		return call(r, http.MethodPut, "/schedules/"+lesson+"/attendance", defaultKey, body).Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, submit(past, newNIK()))
+	assert.Equal(t, http.StatusUnprocessableEntity, submit(future, nik))
+	require.Equal(t, http.StatusOK, submit(past, nik))
+	require.Equal(t, http.StatusOK, submit(past, nik))
+
+	resp := call(r, http.MethodGet, "/schedules/"+past+"/attendance", defaultKey, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var got struct {
+		Records []models.Attendance `json:"records"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+	assert.Len(t, got.Records, 2)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// GetAvailability lists the recurring windows and the unavailability of a
+// teacher ending today or later (or, with ?from=, on or after that date).
+func (h *Handler) GetAvailability(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	nik := c.Param("nik")
+	from := h.today()
+	if v := c.Query("from"); v != "" {
+		d, err := h.parseDate(v)
+		if err != nil {
+			badRequest(c, "invalid from")
+			return
+		}
+		from = d
+	}
+	windows, err := h.Availability.WindowsOf(nik)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	leaves, err := h.Availability.LeavesOf(nik, from, time.Time{})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"teacher_nik": nik, "windows": windows, "unavailability": leaves})
+}
+
+func (h *Handler) AddAvailabilityWindow(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		Weekday   int    `json:"weekday" binding:"required"`
+		JamKeFrom int    `json:"jam_ke_from"`
+		JamKeTo   int    `json:"jam_ke_to"`
+		Note      string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	var errs validate.Errors
+	if in.Weekday < 1 || in.Weekday > 7 {
+		errs.Add("weekday", validate.CodeRange, "must be 1 (Monday) to 7 (Sunday)")
+	}
+	if in.JamKeFrom < 0 {
+		errs.Add("jam_ke_from", validate.CodeRange, "must not be negative")
+	}
+	if in.JamKeTo < 0 {
+		errs.Add("jam_ke_to", validate.CodeRange, "must not be negative")
+	} else if in.JamKeTo > 0 && in.JamKeTo < in.JamKeFrom {
+		errs.Add("jam_ke_to", validate.CodeOrder, "must not be before jam_ke_from")
+	}
+	if len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	w := &models.AvailabilityWindow{
+		TeacherNIK: c.Param("nik"),
+		Weekday:    in.Weekday,
+		JamKeFrom:  in.JamKeFrom,
+		JamKeTo:    in.JamKeTo,
+		Note:       in.Note,
+	}
+	if err := h.Availability.InsertWindow(w); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, w)
+}
+
+func (h *Handler) AddUnavailability(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		StartDate string `json:"start_date" binding:"required"`
+		EndDate   string `json:"end_date" binding:"required"`
+		Reason    string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	start, end, errs := h.dateFields(in.StartDate, in.EndDate)
+	if len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	l := &models.Unavailability{
+		TeacherNIK: c.Param("nik"),
+		StartDate:  start,
+		EndDate:    end,
+		Reason:     in.Reason,
+	}
+	if err := h.Availability.InsertLeave(l); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, l)
+}
+
+func (h *Handler) DeleteAvailabilityWindow(c *gin.Context) {
+	h.deleteAvailability(c, (*repo.AvailabilityRepo).DeleteWindow)
+}
+
+func (h *Handler) DeleteUnavailability(c *gin.Context) {
+	h.deleteAvailability(c, (*repo.AvailabilityRepo).DeleteLeave)
+}
+
+// deleteAvailability deletes an entry of the teacher with del, applied to
+// the repository of the caller's school.
+func (h *Handler) deleteAvailability(c *gin.Context, del func(*repo.AvailabilityRepo, string, primitive.ObjectID) error) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	if err := del(h.Availability, c.Param("nik"), id); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
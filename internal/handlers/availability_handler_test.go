@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAvailabilityIsScopedToSchool(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/teachers/:nik/availability", h.AddAvailabilityWindow)
+	r.DELETE("/teachers/:nik/availability/:id", h.DeleteAvailabilityWindow)
+	r.POST("/teachers/:nik/unavailability", h.AddUnavailability)
+	r.DELETE("/teachers/:nik/unavailability/:id", h.DeleteUnavailability)
+
+	nik := uuid.New().String()
+	for _, tc := range []struct{ path, body string }{
+		{"/teachers/" + nik + "/availability", `{"weekday": 1}`},
+		{"/teachers/" + nik + "/unavailability", `{"start_date": "2024-01-01", "end_date": "2024-01-02", "reason": "cuti"}`},
+	} {
+		for _, owner := range []string{defaultKey, otherKey} {
+			resp := call(r, http.MethodPost, tc.path, owner, tc.body)
+			require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+			var created struct {
+				ID string `json:"id"`
+			}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+			stranger := otherKey
+			if owner == otherKey {
+				stranger = defaultKey
+			}
+			assert.Equal(t, http.StatusNotFound, call(r, http.MethodDelete, tc.path+"/"+created.ID, stranger, "").Code)
+			assert.Equal(t, http.StatusOK, call(r, http.MethodDelete, tc.path+"/"+created.ID, owner, "").Code)
+		}
+	}
+}
+
+func TestGetAvailabilityDefaultsToUpcoming(t *testing.T) {
+	r, h := setupSchools(t)
+	r.GET("/teachers/:nik/availability", h.GetAvailability)
+	r.POST("/teachers/:nik/unavailability", h.AddUnavailability)
+
+	nik := uuid.New().String()
+	for _, body := range []string{
+		`{"start_date": "2024-01-01", "end_date": "2024-01-02", "reason": "cuti"}`,
+		`{"start_date": "2099-01-01", "end_date": "2099-01-02", "reason": "diklat"}`,
+	} {
+		require.Equal(t, http.StatusCreated, call(r, http.MethodPost, "/teachers/"+nik+"/unavailability", defaultKey, body).Code)
+	}
+	leaves := func(query string) int {
+		resp := call(r, http.MethodGet, "/teachers/"+nik+"/availability"+query, defaultKey, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+		var got struct {
+			Unavailability []json.RawMessage `json:"unavailability"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+		return len(got.Unavailability)
+	}
+	assert.Equal(t, 1, leaves(""))
+	assert.Equal(t, 2, leaves("?from=2024-01-01"))
+}
+
+func TestAddAvailabilityReportsFieldErrors(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/teachers/:nik/availability", h.AddAvailabilityWindow)
+	r.POST("/teachers/:nik/unavailability", h.AddUnavailability)
+
+	path := "/teachers/" + newNIK()
+	for _, tc := range []struct{ path, body string }{
+		{path + "/availability", `{"weekday": 8}`},
+		{path + "/availability", `{"weekday": 1, "jam_ke_from": 5, "jam_ke_to": 3}`},
+		{path + "/unavailability", `{"start_date": "2024-01-02", "end_date": "2024-01-01", "reason": "cuti"}`},
+		{path + "/unavailability", `{"start_date": "02-01-2024", "end_date": "2024-01-03", "reason": "cuti"}`},
+	} {
+		resp := call(r, http.MethodPost, tc.path, defaultKey, tc.body)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code, tc.body)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func (h *Handler) GetBellSchedule(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	periods, err := h.Bell.Get()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, periods)
+}
+
+// PutBellSchedule replaces the whole bell schedule.
+func (h *Handler) PutBellSchedule(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in []models.BellPeriod
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	for i, p := range in {
+		if p.JamKe < 1 {
+			badRequest(c, fmt.Sprintf("period %d: jam_ke must be positive", i+1))
+			return
+		}
+		if m, err := jp.Minutes(p.TimeStart, p.TimeEnd); err != nil || m == 0 {
+			badRequest(c, fmt.Sprintf("period %d: invalid time_start/time_end", i+1))
+			return
+		}
+		for _, d := range p.Weekdays {
+			if d < 1 || d > 7 {
+				badRequest(c, fmt.Sprintf("period %d: weekdays must be 1 (Monday) to 7 (Sunday)", i+1))
+				return
+			}
+		}
+	}
+	if err := h.Bell.Replace(in); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, in)
+}
@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestPutBellScheduleReplaces(t *testing.T) {
+	r, h := setupSchools(t)
+	r.GET("/bell-schedule", h.GetBellSchedule)
+	r.PUT("/bell-schedule", h.PutBellSchedule)
+
+	for _, body := range []string{
+		`[{"jam_ke": 1, "time_start": "07:00:00", "time_end": "07:45:00"}, {"jam_ke": 2, "time_start": "07:45:00", "time_end": "08:30:00"}]`,
+		`[{"jam_ke": 1, "time_start": "07:15:00", "time_end": "08:00:00"}]`,
+	} {
+		resp := call(r, http.MethodPut, "/bell-schedule", otherKey, body)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	resp := call(r, http.MethodGet, "/bell-schedule", otherKey, "")
+	var periods []models.BellPeriod
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &periods))
+	require.Len(t, periods, 1)
+	assert.Equal(t, "07:15:00", periods[0].TimeStart)
+}
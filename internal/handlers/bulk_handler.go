@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/bulk"
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+)
+
+// bulkInput is the body shared by the bulk operations. A dry run only
+// reports what would change and hands out the confirm_token the real run
+// must send back.
+type bulkInput struct {
+	StartDate    string `json:"start_date" binding:"required"`
+	EndDate      string `json:"end_date" binding:"required"`
+	ClassCode    string `json:"class_code"`
+	TeacherNIK   string `json:"teacher_nik"`
+	DryRun       bool   `json:"dry_run"`
+	ConfirmToken string `json:"confirm_token"`
+}
+
+type bulkConflict struct {
+	UUID   string    `json:"uuid"`
+	Date   time.Time `json:"date"`
+	JamKe  int       `json:"jam_ke"`
+	Reason string    `json:"reason"`
+}
+
+// bulkMatch parses the filter and loads the lessons it matches.
+func (h *Handler) bulkMatch(c *gin.Context, in bulkInput) (bulk.Filter, []models.Schedule, bool) {
+	var f bulk.Filter
+	var err error
+	if f.Start, err = h.parseDate(in.StartDate); err != nil {
+		badRequest(c, "invalid start_date")
+		return f, nil, false
+	}
+	if f.End, err = h.parseDate(in.EndDate); err != nil {
+		badRequest(c, "invalid end_date")
+		return f, nil, false
+	}
+	if f.End.Before(f.Start) {
+		badRequest(c, "end_date must not be before start_date")
+		return f, nil, false
+	}
+	f.ClassCode, f.TeacherNIK = in.ClassCode, in.TeacherNIK
+
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(f.BSON()))
+	if err != nil {
+		fail(c, err)
+		return f, nil, false
+	}
+	rows := []models.Schedule{}
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		fail(c, err)
+		return f, nil, false
+	}
+	return f, rows, true
+}
+
+func uuidsOf(rows []models.Schedule) []string {
+	out := make([]string, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.UUID)
+	}
+	return out
+}
+
+func datesOf(rows []models.Schedule) []time.Time {
+	out := make([]time.Time, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.Date)
+	}
+	return out
+}
+
+// confirmed answers a dry run or checks the token of a real run. It
+// reports whether the operation should go ahead.
+func confirmed(c *gin.Context, in bulkInput, token string, preview gin.H) bool {
+	if in.DryRun {
+		preview["dry_run"] = true
+		preview["confirm_token"] = token
+		c.JSON(http.StatusOK, preview)
+		return false
+	}
+	if in.ConfirmToken == "" {
+		badRequest(c, "confirm_token required; run with dry_run first")
+		return false
+	}
+	if !hmac.Equal([]byte(in.ConfirmToken), []byte(token)) {
+		staleConfirmation(c)
+		return false
+	}
+	return true
+}
+
+func staleConfirmation(c *gin.Context) {
+	problem.Write(c, http.StatusConflict, problem.CodeStaleConfirmation,
+		"the matching schedules changed since the dry run; repeat the dry run", nil)
+}
+
+func auditFilter(f bulk.Filter) map[string]interface{} {
+	m := map[string]interface{}{
+		"start_date": f.Start.Format("2006-01-02"),
+		"end_date":   f.End.Format("2006-01-02"),
+	}
+	if f.ClassCode != "" {
+		m["class_code"] = f.ClassCode
+	}
+	if f.TeacherNIK != "" {
+		m["teacher_nik"] = f.TeacherNIK
+	}
+	return m
+}
+
+// BulkDelete deletes every lesson matching the filter, e.g. all lessons of
+// a day the school is closed.
+func (h *Handler) BulkDelete(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	var in bulkInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	f, rows, ok := h.bulkMatch(c, in)
+	if !ok {
+		return
+	}
+	uuids := uuidsOf(rows)
+	token := bulk.Token(h.bulkKey, bulk.OpDelete, 0, f, uuids)
+	if !confirmed(c, in, token, gin.H{"count": len(rows), "uuids": uuids}) {
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "no schedules matched", "count": 0})
+		return
+	}
+	if err := h.writable(datesOf(rows)...); err != nil {
+		fail(c, err)
+		return
+	}
+
+	deleted, err := h.Repo.DeleteManyByUUID(uuids)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	h.emitAll(events.Deleted, rows)
+	entry := &models.AuditEntry{
+		Action:     "bulk_delete",
+		Filter:     auditFilter(f),
+		Count:      int(deleted),
+		UUIDs:      uuids,
+		RemoteAddr: c.ClientIP(),
+	}
+	if err := h.Audit.Insert(entry); err != nil {
+		fail(c, fmt.Errorf("deleted but audit entry failed: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted", "count": deleted, "audit_id": entry.ID})
+}
+
+// BulkShift moves every lesson matching the filter by shift_days. The
+// shifted lessons are checked like moved lessons; if any of them conflicts
+// nothing is changed.
+func (h *Handler) BulkShift(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		bulkInput
+		ShiftDays int `json:"shift_days" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	f, rows, ok := h.bulkMatch(c, in.bulkInput)
+	if !ok {
+		return
+	}
+	uuids := uuidsOf(rows)
+
+	shifted := make([]models.Schedule, len(rows))
+	for i, r := range rows {
+		shifted[i] = r
+		shifted[i].Date = r.Date.AddDate(0, 0, in.ShiftDays)
+	}
+	conflicts, warnings, err := h.checkShift(shifted, uuids)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	token := bulk.Token(h.bulkKey, bulk.OpShift, in.ShiftDays, f, uuids)
+	preview := gin.H{"count": len(rows), "uuids": uuids, "conflicts": conflicts}
+	if len(warnings) > 0 {
+		preview["warnings"] = warnings
+	}
+	if !confirmed(c, in.bulkInput, token, preview) {
+		return
+	}
+	if len(conflicts) > 0 {
+		problem.Write(c, http.StatusConflict, problem.CodeScheduleConflict,
+			fmt.Sprintf("%d shifted lessons conflict", len(conflicts)), gin.H{"conflicts": conflicts})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "no schedules matched", "count": 0})
+		return
+	}
+	if err := h.writable(append(datesOf(rows), datesOf(shifted)...)...); err != nil {
+		fail(c, err)
+		return
+	}
+
+	now := time.Now()
+	updates := make(map[string]bson.M, len(shifted))
+	for i := range shifted {
+		s := &shifted[i]
+		if err := h.stamp(s); err != nil {
+			fail(c, err)
+			return
+		}
+		updates[s.UUID] = bson.M{"date": s.Date, "start_at": s.StartAt, "end_at": s.EndAt, "term_id": s.TermID, "updated_at": now}
+	}
+	if err := h.Repo.UpdateManyByUUID(updates); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			staleConfirmation(c)
+			return
+		}
+		fail(c, err)
+		return
+	}
+	for i := range shifted {
+		shifted[i].UpdatedAt = now
+		h.emit(events.Updated, &shifted[i], &rows[i])
+	}
+	entry := &models.AuditEntry{
+		Action:     "bulk_shift",
+		Filter:     auditFilter(f),
+		Params:     map[string]interface{}{"shift_days": in.ShiftDays},
+		Count:      len(shifted),
+		UUIDs:      uuids,
+		RemoteAddr: c.ClientIP(),
+	}
+	if err := h.Audit.Insert(entry); err != nil {
+		fail(c, fmt.Errorf("shifted but audit entry failed: %w", err))
+		return
+	}
+	resp := gin.H{"message": "shifted", "count": len(shifted), "audit_id": entry.ID}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkShift validates the shifted lessons against holidays, the rest of
+// the timetable and workload limits. The lessons being shifted are ignored
+// at their old dates; among themselves they keep their relative positions
+// and so cannot start to overlap.
+func (h *Handler) checkShift(shifted []models.Schedule, uuids []string) ([]bulkConflict, []string, error) {
+	conflicts := []bulkConflict{}
+	if len(shifted) == 0 {
+		return conflicts, nil, nil
+	}
+	from, to := shifted[0].Date, shifted[0].Date
+	for _, s := range shifted {
+		if s.Date.Before(from) {
+			from = s.Date
+		}
+		if s.Date.After(to) {
+			to = s.Date
+		}
+	}
+	holidays, err := h.Holidays.Find(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range shifted {
+		if hol, ok := calendar.HolidayOn(holidays, s.Date); ok {
+			conflicts = append(conflicts, bulkConflict{UUID: s.UUID, Date: s.Date, JamKe: s.JamKe, Reason: "holiday: " + hol.Name})
+			continue
+		}
+		reason, err := h.CheckSlot(s.Date, s.ClassCode, s.TeacherNIK, s.JamKe, s.TimeStart, s.TimeEnd, uuids...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason != "" {
+			conflicts = append(conflicts, bulkConflict{UUID: s.UUID, Date: s.Date, JamKe: s.JamKe, Reason: reason})
+		}
+	}
+
+	warnings, err := h.CheckWorkload(shifted...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(warnings) > 0 && h.blocksWorkload() {
+		conflicts = append(conflicts, bulkConflict{Reason: "workload limit exceeded: " + strings.Join(warnings, "; ")})
+		warnings = nil
+	}
+	return conflicts, warnings, nil
+}
+
+// AuditLog lists the newest batch changes, optionally of one ?action=.
+func (h *Handler) AuditLog(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	rows, err := h.Audit.Find(c.Query("action"), int64(limit))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
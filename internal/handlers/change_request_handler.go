@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// changeInput is a teacher's proposal as submitted.
+type changeInput struct {
+	TeacherNIK   string `json:"teacher_nik" binding:"required"`
+	ScheduleUUID string `json:"schedule_uuid" binding:"required"`
+	Kind         string `json:"kind" binding:"required,oneof=move swap cancel"`
+	Reason       string `json:"reason"`
+	Date         string `json:"date"`
+	JamKe        int    `json:"jam_ke"`
+	TimeStart    string `json:"time_start"`
+	TimeEnd      string `json:"time_end"`
+	SwapUUID     string `json:"swap_uuid"`
+}
+
+// request turns in into a change request, checking the fields its kind
+// needs.
+func (in changeInput) request(h *Handler) (*models.ChangeRequest, error) {
+	cr := &models.ChangeRequest{
+		TeacherNIK:   in.TeacherNIK,
+		ScheduleUUID: in.ScheduleUUID,
+		Kind:         in.Kind,
+		Reason:       in.Reason,
+	}
+	var errs validate.Errors
+	switch in.Kind {
+	case models.ChangeMove:
+		if in.JamKe < 1 {
+			errs.Add("jam_ke", validate.CodeRequired, "a move needs the target jam_ke")
+		}
+		if in.Date != "" {
+			d, err := h.parseDate(in.Date)
+			if err != nil {
+				errs.Add("date", validate.CodeFormat, "must be YYYY-MM-DD, got %q", in.Date)
+			} else {
+				cr.Date = &d
+			}
+		}
+		cr.JamKe, cr.TimeStart, cr.TimeEnd = in.JamKe, in.TimeStart, in.TimeEnd
+	case models.ChangeSwap:
+		if in.SwapUUID == "" {
+			errs.Add("swap_uuid", validate.CodeRequired, "a swap needs the lesson to swap with")
+		} else if in.SwapUUID == in.ScheduleUUID {
+			errs.Add("swap_uuid", validate.CodeRange, "must differ from schedule_uuid")
+		}
+		cr.SwapUUID = in.SwapUUID
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return cr, nil
+}
+
+// movedBy returns the lesson s as a move request would leave it.
+func (h *Handler) movedBy(cr *models.ChangeRequest, s *models.Schedule) (models.Schedule, error) {
+	date := ""
+	if cr.Date != nil {
+		date = cr.Date.Format("2006-01-02")
+	}
+	return h.moveTarget(s, date, cr.JamKe, cr.TimeStart, cr.TimeEnd)
+}
+
+// precheck runs the checks the change would face when applied, without
+// applying it, and returns their warnings.
+func (h *Handler) precheck(cr *models.ChangeRequest, s *models.Schedule) ([]string, error) {
+	switch cr.Kind {
+	case models.ChangeMove:
+		moved, err := h.movedBy(cr, s)
+		if err != nil {
+			return nil, err
+		}
+		_, warnings, err := h.checkUpdate(s, inputOf(&moved))
+		return warnings, err
+	case models.ChangeSwap:
+		other, err := h.Repo.FindByUUID(cr.SwapUUID)
+		if err != nil {
+			return nil, fmt.Errorf("swap_uuid: %w", err)
+		}
+		_, _, warnings, err := h.checkSwap(s, other)
+		return warnings, err
+	}
+	return nil, h.writable(s.Date)
+}
+
+// apply carries out an approved change through the same paths as PUT,
+// Swap and Delete, and returns the lessons as saved.
+func (h *Handler) apply(cr *models.ChangeRequest, s *models.Schedule) ([]models.Schedule, error) {
+	switch cr.Kind {
+	case models.ChangeMove:
+		moved, err := h.movedBy(cr, s)
+		if err != nil {
+			return nil, err
+		}
+		saved, _, err := h.update(s, inputOf(&moved))
+		if err != nil {
+			return nil, err
+		}
+		return []models.Schedule{saved}, nil
+	case models.ChangeSwap:
+		other, err := h.Repo.FindByUUID(cr.SwapUUID)
+		if err != nil {
+			return nil, fmt.Errorf("swap_uuid: %w", err)
+		}
+		saved, _, err := h.swap(s, other)
+		return saved, err
+	}
+	if err := h.writable(s.Date); err != nil {
+		return nil, err
+	}
+	if err := h.Repo.DeleteByUUID(s.UUID); err != nil {
+		return nil, err
+	}
+	h.emit(events.Deleted, s, nil)
+	return []models.Schedule{}, nil
+}
+
+// SubmitChangeRequest records a teacher's request to move, swap or cancel
+// one of their own lessons. The change is pre-checked for conflicts now
+// and applied only once an admin approves it.
+func (h *Handler) SubmitChangeRequest(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in changeInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	cr, err := in.request(h)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	s, ok := h.findForChange(c, cr.ScheduleUUID)
+	if !ok {
+		return
+	}
+	if s.TeacherNIK != cr.TeacherNIK {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden,
+			fmt.Sprintf("schedule %s is not taught by %s", s.UUID, cr.TeacherNIK), nil)
+		return
+	}
+	warnings, err := h.precheck(cr, s)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	cr.Warnings = warnings
+	if err := h.Changes.Insert(cr); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, cr)
+}
+
+// ListChangeRequests lists requests, optionally of one ?status= and
+// ?teacher_nik=.
+func (h *Handler) ListChangeRequests(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Changes.Find(c.Query("status"), c.Query("teacher_nik"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// GetChangeRequest returns one request with its review state.
+func (h *Handler) GetChangeRequest(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	cr, ok := h.changeRequest(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, cr)
+}
+
+// changeRequest loads the request named by :id, answering 409 when
+// pending is set and it was already reviewed.
+func (h *Handler) changeRequest(c *gin.Context, pending bool) (*models.ChangeRequest, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return nil, false
+	}
+	cr, err := h.Changes.Get(id)
+	if err != nil {
+		fail(c, err)
+		return nil, false
+	}
+	if pending && cr.Status != models.RequestPending {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, fmt.Sprintf("change request is already %s", cr.Status), nil)
+		return nil, false
+	}
+	return cr, true
+}
+
+type reviewInput struct {
+	Note string `json:"note"`
+}
+
+// ApproveChangeRequest applies a pending request and records it in the
+// audit log. Like RejectChangeRequest it needs an admin key. The request
+// is marked approved before the change is applied, so that concurrent
+// approvals apply it once; when the change no longer passes its checks
+// nothing is applied and the request returns to pending.
+func (h *Handler) ApproveChangeRequest(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in reviewInput
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, err.Error())
+		return
+	}
+	cr, ok := h.changeRequest(c, true)
+	if !ok {
+		return
+	}
+	s, ok := h.findForChange(c, cr.ScheduleUUID)
+	if !ok {
+		return
+	}
+	if err := h.Changes.Review(cr, models.RequestApproved, in.Note); err != nil {
+		if errors.Is(err, repo.ErrConflict) {
+			problem.Write(c, http.StatusConflict, problem.CodeConflict, "change request is no longer pending", nil)
+			return
+		}
+		fail(c, err)
+		return
+	}
+	saved, err := h.apply(cr, s)
+	if err != nil {
+		if rerr := h.Changes.Reopen(cr); rerr != nil {
+			err = errors.Join(err, fmt.Errorf("returning the request to pending failed: %w", rerr))
+		}
+		fail(c, err)
+		return
+	}
+	uuids := []string{cr.ScheduleUUID}
+	if cr.SwapUUID != "" {
+		uuids = append(uuids, cr.SwapUUID)
+	}
+	entry := &models.AuditEntry{
+		Action:     "change_request_" + cr.Kind,
+		Filter:     map[string]interface{}{"teacher_nik": cr.TeacherNIK},
+		Params:     map[string]interface{}{"change_request_id": cr.ID, "reason": cr.Reason, "note": in.Note},
+		Count:      len(uuids),
+		UUIDs:      uuids,
+		RemoteAddr: c.ClientIP(),
+	}
+	if err := h.Audit.Insert(entry); err != nil {
+		fail(c, fmt.Errorf("applied but audit entry failed: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "approved", "change_request": cr, "schedules": saved, "audit_id": entry.ID})
+}
+
+// RejectChangeRequest closes a pending request without touching the
+// schedule.
+func (h *Handler) RejectChangeRequest(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in reviewInput
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, err.Error())
+		return
+	}
+	cr, ok := h.changeRequest(c, true)
+	if !ok {
+		return
+	}
+	if err := h.Changes.Review(cr, models.RequestRejected, in.Note); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "rejected", "change_request": cr})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func TestChangeInputRequest(t *testing.T) {
+	h := &Handler{Zone: time.UTC}
+	base := changeInput{TeacherNIK: "123", ScheduleUUID: "a", Reason: "rapat dinas"}
+
+	move := base
+	move.Kind, move.Date, move.JamKe = models.ChangeMove, "2024-08-13", 3
+	cr, err := move.request(h)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cr.JamKe)
+	require.NotNil(t, cr.Date)
+	assert.Equal(t, time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC), *cr.Date)
+
+	move.Date, move.JamKe = "13-08-2024", 0
+	_, err = move.request(h)
+	var fields validate.Errors
+	require.True(t, errors.As(err, &fields))
+	assert.Len(t, fields, 2)
+
+	swap := base
+	swap.Kind, swap.SwapUUID = models.ChangeSwap, "a"
+	_, err = swap.request(h)
+	assert.Error(t, err)
+	swap.SwapUUID = "b"
+	cr, err = swap.request(h)
+	require.NoError(t, err)
+	assert.Equal(t, "b", cr.SwapUUID)
+
+	cancel := base
+	cancel.Kind, cancel.JamKe = models.ChangeCancel, 5
+	cr, err = cancel.request(h)
+	require.NoError(t, err)
+	assert.Zero(t, cr.JamKe)
+}
@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestReviewChangeRequest(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/schedules", h.Create)
+	r.GET("/schedules/:uuid", h.GetByUUID)
+	r.DELETE("/schedules/:uuid", h.Delete)
+	r.POST("/change-requests", h.SubmitChangeRequest)
+	r.GET("/change-requests/:id", h.GetChangeRequest)
+	r.POST("/change-requests/:id/approve", h.ApproveChangeRequest)
+	r.POST("/change-requests/:id/reject", h.RejectChangeRequest)
+
+	nik := newNIK()
+	lesson := createLesson(t, r, nik, "2031-02-03", "07:00:00", "08:30:00")
+	move := func(teacher string) string {
+		return fmt.Sprintf(`{"teacher_nik": %q, "schedule_uuid": %q, "kind": "move", "date": "2031-02-04",
+			"jam_ke": 2, "time_start": "09:00:00", "time_end": "10:30:00"}`, teacher, lesson)
+	}
+
+	assert.Equal(t, http.StatusForbidden, call(r, http.MethodPost, "/change-requests", defaultKey, move(newNIK())).Code)
+	resp := call(r, http.MethodPost, "/change-requests", defaultKey, move(nik))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var cr models.ChangeRequest
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cr))
+	path := "/change-requests/" + cr.ID.Hex()
+
+	// Only an admin key reviews.
+	assert.Equal(t, http.StatusForbidden, call(r, http.MethodPost, path+"/approve", defaultKey, "").Code)
+	assert.Equal(t, http.StatusForbidden, call(r, http.MethodPost, path+"/reject", defaultKey, "").Code)
+
+	// Another lesson of the teacher took the target slot since: nothing
+	// is applied and the request stays pending.
+	blocker := createLesson(t, r, nik, "2031-02-04", "09:00:00", "10:30:00")
+	assert.Equal(t, http.StatusConflict, call(r, http.MethodPost, path+"/approve", adminKey, "").Code)
+	resp = call(r, http.MethodGet, path, defaultKey, "")
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cr))
+	assert.Equal(t, models.RequestPending, cr.Status)
+
+	require.Equal(t, http.StatusOK, call(r, http.MethodDelete, "/schedules/"+blocker, defaultKey, "").Code)
+	resp = call(r, http.MethodPost, path+"/approve", adminKey, `{"note": "ok"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = call(r, http.MethodGet, "/schedules/"+lesson, defaultKey, "")
+	var moved models.Schedule
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &moved))
+	assert.Equal(t, "2031-02-04", moved.Date.Format("2006-01-02"))
+	assert.Equal(t, "09:00:00", moved.TimeStart)
+
+	resp = call(r, http.MethodGet, path, defaultKey, "")
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cr))
+	assert.Equal(t, models.RequestApproved, cr.Status)
+	assert.Equal(t, http.StatusConflict, call(r, http.MethodPost, path+"/reject", adminKey, "").Code)
+
+	// Of two concurrent approvals only one applies the change.
+	back := fmt.Sprintf(`{"teacher_nik": %q, "schedule_uuid": %q, "kind": "move", "date": "2031-02-03",
+		"jam_ke": 1, "time_start": "07:00:00", "time_end": "08:30:00"}`, nik, lesson)
+	resp = call(r, http.MethodPost, "/change-requests", defaultKey, back)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cr))
+	codes := make(chan int, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- call(r, http.MethodPost, "/change-requests/"+cr.ID.Hex()+"/approve", adminKey, "").Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	got := []int{}
+	for code := range codes {
+		got = append(got, code)
+	}
+	assert.ElementsMatch(t, []int{http.StatusOK, http.StatusConflict}, got)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// maxCopyDays bounds the source range of CopySchedules.
+const maxCopyDays = 62
+
+// Outcomes of copying one lesson.
+const (
+	copyCopied   = "copied"
+	copyHoliday  = "skipped_holiday"
+	copyLocked   = "skipped_locked_term"
+	copyConflict = "conflict"
+	copyFailed   = "failed"
+)
+
+type copyResult struct {
+	SourceUUID string    `json:"source_uuid"`
+	UUID       string    `json:"uuid,omitempty"`
+	Date       time.Time `json:"date"`
+	JamKe      int       `json:"jam_ke"`
+	ClassCode  string    `json:"class_code"`
+	TeacherNIK string    `json:"teacher_nik"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	Warnings   []string  `json:"warnings,omitempty"`
+}
+
+// CopySchedules copies every lesson of a source date range, optionally of
+// one class or teacher, to the range starting at target_start. Each lesson
+// keeps its weekday offset within the range. Lessons landing on a holiday
+// or in a locked term are skipped; the others go through the same conflict, availability and
+// workload checks as Create. The outcome is reported per lesson.
+func (h *Handler) CopySchedules(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		SourceStart string `json:"source_start" binding:"required"`
+		SourceEnd   string `json:"source_end" binding:"required"`
+		TargetStart string `json:"target_start" binding:"required"`
+		ClassCode   string `json:"class_code"`
+		TeacherNIK  string `json:"teacher_nik"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	start, err := h.parseDate(in.SourceStart)
+	if err != nil {
+		badRequest(c, "invalid source_start")
+		return
+	}
+	end, err := h.parseDate(in.SourceEnd)
+	if err != nil {
+		badRequest(c, "invalid source_end")
+		return
+	}
+	target, err := h.parseDate(in.TargetStart)
+	if err != nil {
+		badRequest(c, "invalid target_start")
+		return
+	}
+	if end.Before(start) || end.Sub(start) > maxCopyDays*24*time.Hour {
+		badRequest(c, fmt.Sprintf("source range must be 0 to %d days", maxCopyDays))
+		return
+	}
+	offset := int(target.Sub(start).Hours() / 24)
+	if offset == 0 {
+		badRequest(c, "target_start must differ from source_start")
+		return
+	}
+
+	filter := bson.M{"date": bson.M{"$gte": start, "$lte": end}}
+	if in.ClassCode != "" {
+		filter["class_code"] = in.ClassCode
+	}
+	if in.TeacherNIK != "" {
+		filter["teacher_nik"] = in.TeacherNIK
+	}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(filter))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		fail(c, err)
+		return
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Date.Equal(rows[j].Date) {
+			return rows[i].Date.Before(rows[j].Date)
+		}
+		if rows[i].JamKe != rows[j].JamKe {
+			return rows[i].JamKe < rows[j].JamKe
+		}
+		return rows[i].ClassCode < rows[j].ClassCode
+	})
+
+	holidays, err := h.Holidays.Find(target, end.AddDate(0, 0, offset))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	results := make([]copyResult, 0, len(rows))
+	counts := map[string]int{copyCopied: 0, copyHoliday: 0, copyLocked: 0, copyConflict: 0, copyFailed: 0}
+	for _, src := range rows {
+		s := src
+		s.ID = primitive.NilObjectID
+		s.UUID = uuid.New().String()
+		s.Date = src.Date.AddDate(0, 0, offset)
+		res := copyResult{
+			SourceUUID: src.UUID,
+			Date:       s.Date,
+			JamKe:      s.JamKe,
+			ClassCode:  s.ClassCode,
+			TeacherNIK: s.TeacherNIK,
+		}
+		h.copyOne(&s, holidays, &res)
+		counts[res.Status]++
+		results = append(results, res)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     fmt.Sprintf("%d of %d lessons copied", counts[copyCopied], len(rows)),
+		"offset_days": offset,
+		"summary":     counts,
+		"results":     results,
+	})
+}
+
+// copyOne checks and inserts one copied lesson, recording the outcome in
+// res.
+func (h *Handler) copyOne(s *models.Schedule, holidays []models.Holiday, res *copyResult) {
+	if hol, ok := calendar.HolidayOn(holidays, s.Date); ok {
+		res.Status, res.Reason = copyHoliday, hol.Name
+		return
+	}
+	if err := h.writable(s.Date); err != nil {
+		res.Status, res.Reason = copyFailed, err.Error()
+		var locked termLockedError
+		if errors.As(err, &locked) {
+			res.Status = copyLocked
+		}
+		return
+	}
+	reason, err := h.CheckSlot(s.Date, s.ClassCode, s.TeacherNIK, s.JamKe, s.TimeStart, s.TimeEnd)
+	if err != nil {
+		res.Status, res.Reason = copyFailed, err.Error()
+		return
+	}
+	if reason != "" {
+		res.Status, res.Reason = copyConflict, reason
+		return
+	}
+	warnings, err := h.CheckWorkload(*s)
+	if err != nil {
+		res.Status, res.Reason = copyFailed, err.Error()
+		return
+	}
+	if len(warnings) > 0 && h.blocksWorkload() {
+		res.Status, res.Reason = copyConflict, "workload limit exceeded: "+strings.Join(warnings, "; ")
+		return
+	}
+	now := time.Now()
+	s.CreatedAt, s.UpdatedAt = now, now
+	if err := h.stamp(s); err != nil {
+		res.Status, res.Reason = copyFailed, err.Error()
+		return
+	}
+	if err := h.Repo.Insert(s); err != nil {
+		res.Status, res.Reason = copyFailed, err.Error()
+		return
+	}
+	h.emit(events.Created, s, nil)
+	res.Status, res.UUID, res.Warnings = copyCopied, s.UUID, warnings
+}
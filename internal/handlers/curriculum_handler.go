@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/curriculum"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func (h *Handler) ListCurriculum(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Curriculum.Find(c.Query("class_code"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// PutCurriculum sets the requirement of one class and subject.
+func (h *Handler) PutCurriculum(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		ClassCode     string  `json:"class_code" binding:"required"`
+		SubjectCode   string  `json:"subject_code" binding:"required"`
+		JPPerWeek     float64 `json:"jp_per_week"`
+		JPPerSemester float64 `json:"jp_per_semester"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if in.JPPerWeek < 0 || in.JPPerSemester < 0 || (in.JPPerWeek == 0 && in.JPPerSemester == 0) {
+		badRequest(c, "jp_per_week or jp_per_semester must be positive")
+		return
+	}
+	req := &models.CurriculumRequirement{
+		ClassCode:     in.ClassCode,
+		SubjectCode:   in.SubjectCode,
+		JPPerWeek:     in.JPPerWeek,
+		JPPerSemester: in.JPPerSemester,
+	}
+	if err := h.Curriculum.Upsert(req); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+func (h *Handler) DeleteCurriculum(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	if err := h.Curriculum.Delete(id); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// CurriculumCoverage compares the requirements with the scheduled and
+// delivered JP of a date range and flags the gaps per class and subject.
+func (h *Handler) CurriculumCoverage(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	sd := c.Query("start_date")
+	ed := c.Query("end_date")
+	if sd == "" || ed == "" {
+		badRequest(c, "start_date and end_date required")
+		return
+	}
+	start, err := h.parseDate(sd)
+	if err != nil {
+		badRequest(c, "invalid start_date")
+		return
+	}
+	end, err := h.parseDate(ed)
+	if err != nil {
+		badRequest(c, "invalid end_date")
+		return
+	}
+	if end.Before(start) {
+		badRequest(c, "end_date must not be before start_date")
+		return
+	}
+	classCode := c.Query("class_code")
+
+	reqs, err := h.Curriculum.Find(classCode)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	filter := bson.M{"date": bson.M{"$gte": start, "$lte": end}}
+	if classCode != "" {
+		filter["class_code"] = classCode
+	}
+	rows, j, err := h.journalRows(filter, "")
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	lines := curriculum.Coverage(reqs, rows, h.JP, start, end, j)
+	gaps := 0
+	for _, l := range lines {
+		if l.Status != curriculum.StatusOK {
+			gaps++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": start.Format("2006-01-02"),
+		"end_date":   end.Format("2006-01-02"),
+		"gaps":       gaps,
+		"coverage":   lines,
+	})
+}
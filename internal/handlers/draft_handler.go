@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/draft"
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// draftOpenError rejects a direct change to published lessons while a
+// draft of their dates is open; the change belongs in the draft.
+type draftOpenError struct {
+	Draft models.Draft
+}
+
+func (e draftOpenError) Error() string {
+	return fmt.Sprintf("draft %s is open for this date; edit it with ?draft=%s", e.Draft.Name, e.Draft.ID.Hex())
+}
+
+// loadOpenDrafts returns the school's open drafts, read once per request.
+func (h *Handler) loadOpenDrafts() ([]models.Draft, error) {
+	if h.draftsLoaded {
+		return h.openDrafts, nil
+	}
+	drafts, err := h.Drafts.Find(models.DraftOpen)
+	if err != nil {
+		return nil, err
+	}
+	h.openDrafts, h.draftsLoaded = drafts, true
+	return drafts, nil
+}
+
+// writable checks that lessons dated on dates may change: no date lies in
+// a locked term and, in a draft, every date lies within the draft's range
+// while, outside a draft, no open draft covers one of them. Every change
+// to lessons checks both their old and new dates.
+func (h *Handler) writable(dates ...time.Time) error {
+	if err := h.unlocked(dates...); err != nil {
+		return err
+	}
+	if h.draft != nil {
+		for _, d := range dates {
+			if d.Before(h.draft.StartDate) || d.After(h.draft.EndDate) {
+				return validate.Errors{{Field: "date", Code: validate.CodeRange,
+					Message: fmt.Sprintf("%s is outside draft %s", d.Format("2006-01-02"), h.draft.Name)}}
+			}
+		}
+		return nil
+	}
+	drafts, err := h.loadOpenDrafts()
+	if err != nil {
+		return err
+	}
+	if d, ok := draft.Covering(drafts, dates...); ok {
+		return draftOpenError{Draft: d}
+	}
+	return nil
+}
+
+// inDraft switches h to the lessons of the open draft named by ?draft=,
+// if any. It reports false after answering a bad, unknown or closed
+// draft.
+func (h *Handler) inDraft(c *gin.Context) (*Handler, bool) {
+	raw := c.Query("draft")
+	if raw == "" {
+		return h, true
+	}
+	d, ok := h.findDraft(c, raw)
+	if !ok {
+		return h, false
+	}
+	if d.Status != models.DraftOpen {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, fmt.Sprintf("draft is %s", d.Status), nil)
+		return h, false
+	}
+	t := *h
+	t.draft = d
+	t.published = h
+	t.Coll = h.Drafts.Lessons
+	t.Repo = h.Repo.InDraft(h.Drafts.Lessons, d.ID)
+	return &t, true
+}
+
+func (h *Handler) findDraft(c *gin.Context, raw string) (*models.Draft, bool) {
+	id, err := primitive.ObjectIDFromHex(raw)
+	if err != nil {
+		badRequest(c, "invalid draft id")
+		return nil, false
+	}
+	d, err := h.Drafts.Get(id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			notFound(c, fmt.Sprintf("draft %s not found", raw))
+			return nil, false
+		}
+		fail(c, err)
+		return nil, false
+	}
+	return d, true
+}
+
+// publishedIn returns the published lessons dated within d's range.
+func (h *Handler) publishedIn(d *models.Draft) ([]models.Schedule, error) {
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(bson.M{"date": bson.M{"$gte": d.StartDate, "$lte": d.EndDate}}))
+	if err != nil {
+		return nil, err
+	}
+	rows := []models.Schedule{}
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListDrafts lists drafts, optionally of one ?status=.
+func (h *Handler) ListDrafts(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Drafts.Find(c.Query("status"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// OpenDraft starts a draft of a date range as a copy of its published
+// lessons. Until the draft is published or discarded, lessons of the range
+// can only be changed in the draft.
+func (h *Handler) OpenDraft(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		Name      string `json:"name" binding:"required"`
+		StartDate string `json:"start_date" binding:"required"`
+		EndDate   string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	start, end, errs := h.dateFields(in.StartDate, in.EndDate)
+	if strings.TrimSpace(in.Name) == "" {
+		errs.Add("name", validate.CodeRequired, "is required")
+	}
+	if len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	open, err := h.loadOpenDrafts()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if other, ok := draft.Overlapping(open, start, end); ok {
+		problem.Write(c, http.StatusConflict, problem.CodeDraftOpen,
+			fmt.Sprintf("draft %s already covers part of this range", other.Name), gin.H{"draft_id": other.ID})
+		return
+	}
+
+	d := &models.Draft{Name: in.Name, StartDate: start, EndDate: end}
+	lessons, err := h.publishedIn(d)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.Drafts.Open(d, lessons); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"draft": d, "lessons": len(lessons)})
+}
+
+func (h *Handler) GetDraft(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	d, ok := h.findDraft(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// DraftDiff shows what publishing the draft would change.
+func (h *Handler) DraftDiff(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	d, ok := h.findDraft(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if d.Status != models.DraftOpen {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, fmt.Sprintf("draft is %s", d.Status), nil)
+		return
+	}
+	published, err := h.publishedIn(d)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	drafted, err := h.Drafts.LessonsOf(d.ID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"draft": d, "diff": draft.Compare(published, drafted)})
+}
+
+// PublishDraft replaces the published lessons of the draft's range by the
+// draft's lessons in one transaction.
+func (h *Handler) PublishDraft(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	d, ok := h.findDraft(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if d.Status != models.DraftOpen {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, fmt.Sprintf("draft is %s", d.Status), nil)
+		return
+	}
+	published, err := h.publishedIn(d)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	drafted, err := h.Drafts.LessonsOf(d.ID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.unlocked(append(datesOf(published), datesOf(drafted)...)...); err != nil {
+		fail(c, err)
+		return
+	}
+	diff := draft.Compare(published, drafted)
+	for i := range drafted {
+		if drafted[i].TermID, err = h.termOf(drafted[i].Date); err != nil {
+			fail(c, err)
+			return
+		}
+	}
+
+	if err := h.Drafts.Publish(d, h.Coll, drafted); err != nil {
+		if errors.Is(err, repo.ErrConflict) {
+			problem.Write(c, http.StatusConflict, problem.CodeConflict, "draft is no longer open", nil)
+			return
+		}
+		fail(c, err)
+		return
+	}
+	h.emitAll(events.Created, diff.Added)
+	h.emitAll(events.Deleted, diff.Removed)
+	for i := range diff.Changed {
+		ch := &diff.Changed[i]
+		h.emit(events.Updated, &ch.After, &ch.Before)
+	}
+	summary := gin.H{"added": len(diff.Added), "removed": len(diff.Removed), "changed": len(diff.Changed)}
+	entry := &models.AuditEntry{
+		Action:     "publish_draft",
+		Filter:     map[string]interface{}{"start_date": d.StartDate, "end_date": d.EndDate},
+		Params:     map[string]interface{}{"draft_id": d.ID, "summary": summary},
+		Count:      len(drafted),
+		UUIDs:      uuidsOf(drafted),
+		RemoteAddr: c.ClientIP(),
+	}
+	if err := h.Audit.Insert(entry); err != nil {
+		fail(c, fmt.Errorf("published but audit entry failed: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "published", "draft": d, "summary": summary, "audit_id": entry.ID})
+}
+
+// DiscardDraft drops an open draft and its lessons.
+func (h *Handler) DiscardDraft(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid draft id")
+		return
+	}
+	if err := h.Drafts.Discard(id); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "discarded"})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func TestWritable(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	week := models.Draft{Name: "week 2", StartDate: day(12), EndDate: day(16), Status: models.DraftOpen}
+	terms := []models.Term{{Name: "Ganjil", StartDate: day(1), EndDate: day(9), Locked: true}}
+
+	published := &Handler{terms: terms, termsLoaded: true, openDrafts: []models.Draft{week}, draftsLoaded: true}
+	assert.NoError(t, published.writable(day(10), day(17)))
+	var open draftOpenError
+	assert.True(t, errors.As(published.writable(day(10), day(13)), &open))
+	assert.Equal(t, "week 2", open.Draft.Name)
+	var locked termLockedError
+	assert.True(t, errors.As(published.writable(day(9)), &locked))
+
+	drafting := &Handler{terms: terms, termsLoaded: true, draft: &week}
+	assert.NoError(t, drafting.writable(day(12), day(16)))
+	var fields validate.Errors
+	assert.True(t, errors.As(drafting.writable(day(13), day(17)), &fields))
+}
@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftWorkloadCountsPublishedDays(t *testing.T) {
+	t.Setenv("WORKLOAD_MAX_WEEKLY_JP", "2")
+	r, h := setupSchools(t)
+	r.POST("/schedules", h.Create)
+	r.POST("/drafts", h.OpenDraft)
+	r.DELETE("/drafts/:id", h.DiscardDraft)
+
+	nik := newNIK()
+	lesson := func(date string) string {
+		return fmt.Sprintf(`{"class_code": %q, "class_name": "X", "subject_code": "MTK", "teacher_nik": %q,
+			"teacher_name": "Budi", "date": %q, "jam_ke": 1, "time_start": "07:00:00", "time_end": "08:30:00"}`,
+			uuid.New().String(), nik, date)
+	}
+
+	// Monday 2031-01-06 is published, the rest of the week is drafted.
+	resp := call(r, http.MethodPost, "/schedules", defaultKey, lesson("2031-01-06"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	resp = call(r, http.MethodPost, "/drafts", defaultKey, `{"name": "week 2", "start_date": "2031-01-08", "end_date": "2031-01-11"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var opened struct {
+		Draft struct {
+			ID string `json:"id"`
+		} `json:"draft"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &opened))
+	id := opened.Draft.ID
+	t.Cleanup(func() { call(r, http.MethodDelete, "/drafts/"+id, defaultKey, "") })
+
+	resp = call(r, http.MethodPost, "/schedules?draft="+id, defaultKey, lesson("2031-01-08"))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var created struct {
+		Warnings []string `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Warnings)
+}
+
+func TestOpenDraftReportsFieldErrors(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/drafts", h.OpenDraft)
+
+	for _, body := range []string{
+		`{"start_date": "2031-01-08", "end_date": "2031-01-11"}`,
+		`{"name": " ", "start_date": "2031-01-08", "end_date": "2031-01-11"}`,
+		`{"name": "week 2", "start_date": "2031-01-11", "end_date": "2031-01-08"}`,
+		`{"name": "week 2", "start_date": "08-01-2031", "end_date": "2031-01-11"}`,
+	} {
+		assert.Equal(t, http.StatusUnprocessableEntity, call(r, http.MethodPost, "/drafts", defaultKey, body).Code, body)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// fail answers with the problem matching err. Unexpected errors are logged
+// with the request ID and reported without their message, which may come
+// straight from the database driver.
+func fail(c *gin.Context, err error) {
+	var fields validate.Errors
+	var locked termLockedError
+	var drafting draftOpenError
+	var slot slotConflictError
+	var load workloadError
+	switch {
+	case errors.As(err, &fields):
+		invalid(c, fields)
+	case errors.As(err, &locked):
+		problem.Write(c, http.StatusConflict, problem.CodeTermLocked, err.Error(), gin.H{"term_id": locked.Term.ID})
+	case errors.As(err, &drafting):
+		problem.Write(c, http.StatusConflict, problem.CodeDraftOpen, err.Error(), gin.H{"draft_id": drafting.Draft.ID})
+	case errors.As(err, &slot):
+		scheduleConflict(c, string(slot))
+	case errors.As(err, &load):
+		workloadExceeded(c, string(load))
+	case errors.Is(err, repo.ErrNotFound):
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, err.Error(), nil)
+	case errors.Is(err, repo.ErrConflict):
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, err.Error(), nil)
+	case errors.Is(err, repo.ErrValidation):
+		problem.Write(c, http.StatusUnprocessableEntity, problem.CodeValidation, err.Error(), nil)
+	case errors.Is(err, repo.ErrNoTransactions):
+		problem.Write(c, http.StatusServiceUnavailable, problem.CodeNoTransactions, err.Error(), nil)
+	default:
+		log.Printf("request %s: %s %s: %v", c.GetString(problem.RequestIDKey), c.Request.Method, c.Request.URL.Path, err)
+		problem.Write(c, http.StatusInternalServerError, problem.CodeInternal, "internal server error", nil)
+	}
+}
+
+func badRequest(c *gin.Context, detail string) {
+	problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, detail, nil)
+}
+
+func notFound(c *gin.Context, detail string) {
+	problem.Write(c, http.StatusNotFound, problem.CodeNotFound, detail, nil)
+}
+
+// slotConflictError carries the reason CheckSlot rejected a lesson.
+type slotConflictError string
+
+func (e slotConflictError) Error() string { return string(e) }
+
+// workloadError carries the workload limits a blocked change exceeds.
+type workloadError string
+
+func (e workloadError) Error() string { return string(e) }
+
+// scheduleConflict rejects a lesson that overlaps another one or falls
+// outside the teacher's availability.
+func scheduleConflict(c *gin.Context, reason string) {
+	problem.Write(c, http.StatusConflict, problem.CodeScheduleConflict, reason, gin.H{"reason": reason})
+}
+
+// workloadExceeded rejects a change that breaks a workload maximum while
+// WORKLOAD_MODE is block.
+func workloadExceeded(c *gin.Context, reason string) {
+	problem.Write(c, http.StatusConflict, problem.CodeWorkloadExceeded, reason, gin.H{"reason": reason})
+}
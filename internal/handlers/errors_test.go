@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func TestFailMapsDomainErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{repo.ErrNotFound, http.StatusNotFound, problem.CodeNotFound, "not found"},
+		{fmt.Errorf("teacher: %w", repo.ErrNotFound), http.StatusNotFound, problem.CodeNotFound, "teacher: not found"},
+		{repo.ErrConflict, http.StatusConflict, problem.CodeConflict, "already exists"},
+		{termLockedError{Term: models.Term{Name: "2023/2024 Ganjil"}}, http.StatusConflict, problem.CodeTermLocked, "term 2023/2024 Ganjil is locked"},
+		{slotConflictError("teacher 123 already teaches at that time"), http.StatusConflict, problem.CodeScheduleConflict, "teacher 123 already teaches at that time"},
+		{workloadError("weekly JP 42 exceeds 40"), http.StatusConflict, problem.CodeWorkloadExceeded, "weekly JP 42 exceeds 40"},
+		{draftOpenError{Draft: models.Draft{Name: "week 2"}}, http.StatusConflict, problem.CodeDraftOpen,
+			"draft week 2 is open for this date; edit it with ?draft=000000000000000000000000"},
+		{fmt.Errorf("%w: no updates", repo.ErrValidation), http.StatusUnprocessableEntity, problem.CodeValidation, "invalid input: no updates"},
+		{validate.Errors{{Field: "jam_ke", Code: validate.CodeRange, Message: "must be at least 1"}},
+			http.StatusUnprocessableEntity, problem.CodeValidation, "jam_ke: must be at least 1"},
+		{repo.ErrNoTransactions, http.StatusServiceUnavailable, problem.CodeNoTransactions, repo.ErrNoTransactions.Error()},
+		// Driver errors must not leak.
+		{errors.New("connection(localhost:27017) refused"), http.StatusInternalServerError, problem.CodeInternal, "internal server error"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/schedules/x", nil)
+		c.Set(problem.RequestIDKey, "req-1")
+
+		fail(c, tc.err)
+
+		assert.Equal(t, tc.status, w.Code, tc.err.Error())
+		assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, tc.code, body["code"])
+		assert.Equal(t, tc.detail, body["detail"])
+		assert.Equal(t, "req-1", body["request_id"])
+	}
+}
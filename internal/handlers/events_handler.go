@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// keepAlive is how often an idle stream sends a comment, so proxies keep
+// the connection open.
+const keepAlive = 25 * time.Second
+
+// emit publishes a change to a published lesson. Edits inside a draft are
+// not published, and when a change stream feeds the bus (see
+// repo.Watch) it publishes instead of the handlers.
+func (h *Handler) emit(typ string, s, prev *models.Schedule) {
+	if h.Events == nil || h.draft != nil || h.Cfg.EventsChangeStream {
+		return
+	}
+	h.Events.Publish(events.Event{Type: typ, SchoolID: h.School, UUID: s.UUID, Schedule: s, Previous: prev})
+}
+
+// emitAll publishes typ for each of rows.
+func (h *Handler) emitAll(typ string, rows []models.Schedule) {
+	for i := range rows {
+		h.emit(typ, &rows[i], nil)
+	}
+}
+
+// StreamEvents streams schedule changes of the school as Server-Sent
+// Events, optionally only those of one ?class_code= or ?teacher_nik=.
+// Each event is named by its type and carries the events.Event as JSON.
+func (h *Handler) StreamEvents(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	f := events.Filter{School: h.School, ClassCode: c.Query("class_code"), TeacherNIK: c.Query("teacher_nik")}
+	ch, stop := h.Events.Subscribe(f, 64)
+	defer stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	c.SSEvent("ready", gin.H{"class_code": f.ClassCode, "teacher_nik": f.TeacherNIK})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return false
+			}
+			c.SSEvent(e.Type, e)
+			return true
+		case <-ticker.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestEmit(t *testing.T) {
+	bus := events.NewBus()
+	ch, stop := bus.Subscribe(events.Filter{School: "a", TeacherNIK: "111"}, 8)
+	defer stop()
+	lesson := &models.Schedule{UUID: "u1", TeacherNIK: "111"}
+
+	h := &Handler{Events: bus, School: "a"}
+	h.emit(events.Created, lesson, nil)
+	e := <-ch
+	assert.Equal(t, events.Created, e.Type)
+	assert.Equal(t, "u1", e.UUID)
+	assert.Equal(t, "a", e.SchoolID)
+
+	drafting := &Handler{Events: bus, School: "a", draft: &models.Draft{}}
+	drafting.emit(events.Updated, lesson, lesson)
+	streamed := &Handler{Events: bus, School: "a"}
+	streamed.Cfg.EventsChangeStream = true
+	streamed.emit(events.Deleted, lesson, nil)
+	assert.Empty(t, ch, "draft edits and change-stream mode publish nothing")
+
+	(&Handler{School: "a"}).emit(events.Created, lesson, nil)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/availability"
+	"github.com/mghazyfawazh/EGS/internal/bell"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+)
+
+// maxFreeSlotDays bounds the range searched by FreeSlots.
+const maxFreeSlotDays = 62
+
+type freeSlot struct {
+	Date      string `json:"date"`
+	Weekday   int    `json:"weekday"`
+	JamKe     int    `json:"jam_ke"`
+	TimeStart string `json:"time_start"`
+	TimeEnd   string `json:"time_end"`
+	// Adjacent counts lessons of the class or teacher directly before or
+	// after the slot on the same day.
+	Adjacent int `json:"adjacent"`
+
+	date time.Time
+}
+
+// findFreeSlots lists every bell period in [start, end] in which neither
+// the class nor the teacher has an overlapping lesson (with the same
+// semantics as DetectConflict) and the teacher is available. Either
+// classCode or teacherNIK may be empty.
+func findFreeSlots(periods []models.BellPeriod, lessons []models.Schedule, windows []models.AvailabilityWindow,
+	leaves []models.Unavailability, classCode, teacherNIK string, start, end time.Time) ([]freeSlot, error) {
+
+	byDate := map[time.Time][]models.Schedule{}
+	for _, l := range lessons {
+		if (classCode != "" && l.ClassCode == classCode) || (teacherNIK != "" && l.TeacherNIK == teacherNIK) {
+			byDate[l.Date] = append(byDate[l.Date], l)
+		}
+	}
+
+	out := []freeSlot{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		weekday := availability.ISOWeekday(d)
+		for _, p := range bell.On(periods, weekday) {
+			if teacherNIK != "" && availability.Check(windows, leaves, d, p.JamKe) != "" {
+				continue
+			}
+			busy := false
+			adjacent := 0
+			for _, l := range byDate[d] {
+				ok, err := timeOverlap(l.TimeStart, l.TimeEnd, p.TimeStart, p.TimeEnd)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					busy = true
+					break
+				}
+				if l.JamKe == p.JamKe-1 || l.JamKe == p.JamKe+1 {
+					adjacent++
+				}
+			}
+			if busy {
+				continue
+			}
+			out = append(out, freeSlot{
+				Date:      d.Format("2006-01-02"),
+				Weekday:   weekday,
+				JamKe:     p.JamKe,
+				TimeStart: p.TimeStart,
+				TimeEnd:   p.TimeEnd,
+				Adjacent:  adjacent,
+				date:      d,
+			})
+		}
+	}
+	return out, nil
+}
+
+// rankFreeSlots orders slots by preference: "contiguous" puts slots next
+// to existing lessons first, "early" prefers early periods of the day;
+// anything else keeps chronological order.
+func rankFreeSlots(slots []freeSlot, prefer string) {
+	chrono := func(a, b freeSlot) bool {
+		if !a.date.Equal(b.date) {
+			return a.date.Before(b.date)
+		}
+		return a.JamKe < b.JamKe
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		a, b := slots[i], slots[j]
+		switch prefer {
+		case "contiguous":
+			if a.Adjacent != b.Adjacent {
+				return a.Adjacent > b.Adjacent
+			}
+		case "early":
+			if a.JamKe != b.JamKe {
+				return a.JamKe < b.JamKe
+			}
+		}
+		return chrono(a, b)
+	})
+}
+
+// FreeSlots returns the periods in which the given class and/or teacher
+// are free, e.g. to find a new slot for a lesson.
+func (h *Handler) FreeSlots(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	classCode := c.Query("class_code")
+	nik := c.Query("teacher_nik")
+	if classCode == "" && nik == "" {
+		badRequest(c, "class_code or teacher_nik required")
+		return
+	}
+	sd := c.Query("start_date")
+	ed := c.Query("end_date")
+	if sd == "" || ed == "" {
+		badRequest(c, "start_date and end_date required")
+		return
+	}
+	start, err := h.parseDate(sd)
+	if err != nil {
+		badRequest(c, "invalid start_date")
+		return
+	}
+	end, err := h.parseDate(ed)
+	if err != nil {
+		badRequest(c, "invalid end_date")
+		return
+	}
+	if end.Before(start) || end.Sub(start) > maxFreeSlotDays*24*time.Hour {
+		badRequest(c, "date range must be 0 to 62 days")
+		return
+	}
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	periods, err := h.bellSchedule(start, end)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if len(periods) == 0 {
+		problem.Write(c, http.StatusUnprocessableEntity, problem.CodeValidation, "no bell schedule configured", nil)
+		return
+	}
+
+	or := bson.A{}
+	if classCode != "" {
+		or = append(or, bson.M{"class_code": classCode})
+	}
+	if nik != "" {
+		or = append(or, bson.M{"teacher_nik": nik})
+	}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(bson.M{"date": bson.M{"$gte": start, "$lte": end}, "$or": or}))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	var lessons []models.Schedule
+	if err := cur.All(h.Ctx, &lessons); err != nil {
+		fail(c, err)
+		return
+	}
+
+	var windows []models.AvailabilityWindow
+	var leaves []models.Unavailability
+	if nik != "" {
+		if windows, err = h.Availability.WindowsOf(nik); err != nil {
+			fail(c, err)
+			return
+		}
+		if leaves, err = h.Availability.LeavesOf(nik, start, end); err != nil {
+			fail(c, err)
+			return
+		}
+	}
+
+	slots, err := findFreeSlots(periods, lessons, windows, leaves, classCode, nik, start, end)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	rankFreeSlots(slots, c.Query("prefer"))
+	total := len(slots)
+	if len(slots) > limit {
+		slots = slots[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "slots": slots})
+}
+
+// bellSchedule returns the configured bell schedule or, when none is
+// configured, the one implied by the lessons of [start, end].
+func (h *Handler) bellSchedule(start, end time.Time) ([]models.BellPeriod, error) {
+	periods, err := h.Bell.Get()
+	if err != nil || len(periods) > 0 {
+		return periods, err
+	}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(bson.M{"date": bson.M{"$gte": start, "$lte": end}}))
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return nil, err
+	}
+	return bell.FromLessons(rows), nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestFindFreeSlots(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	d := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+	periods := []models.BellPeriod{
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{JamKe: 2, TimeStart: "07:50:00", TimeEnd: "08:35:00"},
+		{JamKe: 3, TimeStart: "08:40:00", TimeEnd: "09:25:00"},
+	}
+	lessons := []models.Schedule{
+		{ClassCode: "X1", TeacherNIK: "9", Date: d(1), JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{ClassCode: "X2", TeacherNIK: "1", Date: d(1), JamKe: 3, TimeStart: "08:40:00", TimeEnd: "09:25:00"},
+		{ClassCode: "X3", TeacherNIK: "8", Date: d(1), JamKe: 2, TimeStart: "07:50:00", TimeEnd: "08:35:00"},
+	}
+	leaves := []models.Unavailability{{StartDate: d(2), EndDate: d(2), Reason: "cuti"}}
+
+	slots, err := findFreeSlots(periods, lessons, nil, leaves, "X1", "1", d(1), d(2))
+	assert.NoError(t, err)
+	assert.Len(t, slots, 1)
+	assert.Equal(t, 2, slots[0].JamKe)
+	assert.Equal(t, 2, slots[0].Adjacent)
+
+	// Sunday has no periods, Saturday does.
+	slots, err = findFreeSlots(periods, nil, nil, nil, "X1", "", d(6), d(7))
+	assert.NoError(t, err)
+	assert.Len(t, slots, 3)
+
+	slots, _ = findFreeSlots(periods, lessons, nil, nil, "X1", "", d(1), d(2))
+	rankFreeSlots(slots, "contiguous")
+	assert.Equal(t, "2024-01-01", slots[0].Date)
+	assert.Equal(t, 2, slots[0].JamKe)
+	rankFreeSlots(slots, "early")
+	assert.Equal(t, "2024-01-02", slots[0].Date)
+	assert.Equal(t, 1, slots[0].JamKe)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// ListHolidays lists holidays, optionally only those overlapping
+// ?start_date= and ?end_date=.
+func (h *Handler) ListHolidays(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var from, to time.Time
+	var err error
+	if sd := c.Query("start_date"); sd != "" {
+		if from, err = h.parseDate(sd); err != nil {
+			badRequest(c, "invalid start_date")
+			return
+		}
+	}
+	if ed := c.Query("end_date"); ed != "" {
+		if to, err = h.parseDate(ed); err != nil {
+			badRequest(c, "invalid end_date")
+			return
+		}
+	}
+	rows, err := h.Holidays.Find(from, to)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// AddHoliday records a school-wide day off. end_date defaults to
+// start_date for single-day holidays.
+func (h *Handler) AddHoliday(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		StartDate string `json:"start_date" binding:"required"`
+		EndDate   string `json:"end_date"`
+		Name      string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	start, err := h.parseDate(in.StartDate)
+	if err != nil {
+		badRequest(c, "invalid start_date")
+		return
+	}
+	end := start
+	if in.EndDate != "" {
+		if end, err = h.parseDate(in.EndDate); err != nil {
+			badRequest(c, "invalid end_date")
+			return
+		}
+	}
+	if end.Before(start) {
+		badRequest(c, "end_date must not be before start_date")
+		return
+	}
+	hol := &models.Holiday{StartDate: start, EndDate: end, Name: in.Name}
+	if err := h.Holidays.Insert(hol); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, hol)
+}
+
+func (h *Handler) DeleteHoliday(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	if err := h.Holidays.Delete(id); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/payroll"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func (h *Handler) ListRates(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Rates.FindAll()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// PutRate sets the rate of one teacher or of one employment type.
+func (h *Handler) PutRate(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in models.HonorariumRate
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if errs := checkRate(in); len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	if err := h.Rates.Upsert(&in); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, in)
+}
+
+// checkRate validates a rate as sent to PutRate.
+func checkRate(rt models.HonorariumRate) validate.Errors {
+	var errs validate.Errors
+	if (rt.TeacherNIK == "") == (rt.EmploymentType == "") {
+		errs.Add("teacher_nik", validate.CodeRequired, "exactly one of teacher_nik or employment_type is required")
+	}
+	if rt.RegularRate < 0 {
+		errs.Add("regular_rate", validate.CodeRange, "must not be negative")
+	}
+	if rt.ExtraRate != nil && *rt.ExtraRate < 0 {
+		errs.Add("extra_rate", validate.CodeRange, "must not be negative")
+	}
+	if rt.SubstituteRate != nil && *rt.SubstituteRate < 0 {
+		errs.Add("substitute_rate", validate.CodeRange, "must not be negative")
+	}
+	return errs
+}
+
+func (h *Handler) DeleteRate(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	if err := h.Rates.Delete(id); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// honorarium loads everything needed for the honorarium of a period and
+// writes the error response itself when something fails.
+func (h *Handler) honorarium(c *gin.Context) (payroll.Summary, time.Time, time.Time, bool) {
+	sd := c.Query("start_date")
+	ed := c.Query("end_date")
+	if sd == "" || ed == "" {
+		badRequest(c, "start_date and end_date required")
+		return payroll.Summary{}, time.Time{}, time.Time{}, false
+	}
+	start, err := h.parseDate(sd)
+	if err != nil {
+		badRequest(c, "invalid start_date")
+		return payroll.Summary{}, time.Time{}, time.Time{}, false
+	}
+	end, err := h.parseDate(ed)
+	if err != nil {
+		badRequest(c, "invalid end_date")
+		return payroll.Summary{}, time.Time{}, time.Time{}, false
+	}
+
+	filter := bson.M{"date": bson.M{"$gte": start, "$lte": end}}
+	nik := c.Query("teacher_nik")
+	rows, j, err := h.journalRows(filter, nik)
+	if err != nil {
+		fail(c, err)
+		return payroll.Summary{}, time.Time{}, time.Time{}, false
+	}
+	if nik != "" {
+		// Own lessons taken by a substitute are paid to the substitute.
+		taught := rows[:0]
+		for _, s := range rows {
+			if by, _ := j.TaughtBy(s); by == nik {
+				taught = append(taught, s)
+			}
+		}
+		rows = taught
+	}
+
+	teacherList, err := h.Teachers.FindAll()
+	if err != nil {
+		fail(c, err)
+		return payroll.Summary{}, time.Time{}, time.Time{}, false
+	}
+	teachers := make(map[string]models.Teacher, len(teacherList))
+	for _, t := range teacherList {
+		teachers[t.NIK] = t
+	}
+	rates, err := h.Rates.FindAll()
+	if err != nil {
+		fail(c, err)
+		return payroll.Summary{}, time.Time{}, time.Time{}, false
+	}
+
+	sum := payroll.Compute(rows, h.JP, teachers, payroll.NewRates(rates), j)
+	return sum, start, end, true
+}
+
+// Honorarium returns the honorarium per teacher for the payroll system.
+func (h *Handler) Honorarium(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	sum, start, end, ok := h.honorarium(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":    start.Format("2006-01-02"),
+		"end_date":      end.Format("2006-01-02"),
+		"lines":         sum.Lines,
+		"total_jp":      sum.TotalJP,
+		"total_amount":  sum.TotalAmount,
+		"missing_rates": sum.MissingRates,
+	})
+}
+
+// ExportHonorarium writes the honorarium as an Excel report with totals and
+// a sign-off block (?approver_name=, ?approver_nip=, ?approver_title=,
+// ?place=).
+func (h *Handler) ExportHonorarium(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	sum, start, end, ok := h.honorarium(c)
+	if !ok {
+		return
+	}
+
+	f := excelize.NewFile()
+	sheet := "Honorarium"
+	f.SetSheetName("Sheet1", sheet)
+
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 12},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#FFFF00"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		Border:    border,
+	})
+	moneyFmt := "#,##0"
+	moneyStyle, _ := f.NewStyle(&excelize.Style{Border: border, CustomNumFmt: &moneyFmt})
+	cellStyle, _ := f.NewStyle(&excelize.Style{Border: border})
+	totalStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, Border: border, CustomNumFmt: &moneyFmt})
+
+	title := "Rekap Honorarium Mengajar"
+	if h.Cfg.SchoolName != "" {
+		title += " " + h.Cfg.SchoolName
+	}
+	f.MergeCell(sheet, "A1", "K1")
+	f.SetCellValue(sheet, "A1", title)
+	f.MergeCell(sheet, "A2", "K2")
+	f.SetCellValue(sheet, "A2", fmt.Sprintf("Periode %s s.d. %s", start.Format("02/01/2006"), end.Format("02/01/2006")))
+	f.SetCellStyle(sheet, "A1", "A2", titleStyle)
+
+	headers := []string{"No", "NIK", "Nama Pengajar", "Status Kepegawaian",
+		"JP Reguler", "JP Tambahan", "JP Pengganti",
+		"Tarif Reguler", "Tarif Tambahan", "Tarif Pengganti", "Jumlah (Rp)"}
+	for i, hd := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 4)
+		f.SetCellValue(sheet, cell, hd)
+	}
+	f.SetCellStyle(sheet, "A4", "K4", headerStyle)
+
+	rowIndex := 5
+	for i, l := range sum.Lines {
+		values := []interface{}{i + 1, l.TeacherNIK, l.TeacherName, l.EmploymentType,
+			l.RegularJP, l.ExtraJP, l.SubstituteJP,
+			l.RegularRate, l.ExtraRate, l.SubstituteRate, l.Amount}
+		if l.RateMissing {
+			values[3] = fmt.Sprintf("%s (tarif belum diatur)", l.EmploymentType)
+		}
+		for j, v := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, rowIndex)
+			f.SetCellValue(sheet, cell, v)
+		}
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", rowIndex), fmt.Sprintf("G%d", rowIndex), cellStyle)
+		f.SetCellStyle(sheet, fmt.Sprintf("H%d", rowIndex), fmt.Sprintf("K%d", rowIndex), moneyStyle)
+		rowIndex++
+	}
+
+	f.MergeCell(sheet, fmt.Sprintf("A%d", rowIndex), fmt.Sprintf("D%d", rowIndex))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", rowIndex), "Total")
+	f.MergeCell(sheet, fmt.Sprintf("E%d", rowIndex), fmt.Sprintf("G%d", rowIndex))
+	f.SetCellValue(sheet, fmt.Sprintf("E%d", rowIndex), sum.TotalJP)
+	f.MergeCell(sheet, fmt.Sprintf("H%d", rowIndex), fmt.Sprintf("J%d", rowIndex))
+	f.SetCellValue(sheet, fmt.Sprintf("K%d", rowIndex), sum.TotalAmount)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", rowIndex), fmt.Sprintf("K%d", rowIndex), totalStyle)
+
+	// Sign-off block under the table, right aligned.
+	place := c.Query("place")
+	approverTitle := c.DefaultQuery("approver_title", "Kepala Sekolah")
+	signRow := rowIndex + 3
+	dateLine := h.today().Format("02/01/2006")
+	if place != "" {
+		dateLine = place + ", " + dateLine
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("I%d", signRow), dateLine)
+	f.SetCellValue(sheet, fmt.Sprintf("I%d", signRow+1), "Mengetahui,")
+	f.SetCellValue(sheet, fmt.Sprintf("I%d", signRow+2), approverTitle)
+	name := c.Query("approver_name")
+	if name == "" {
+		name = "(..............................)"
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("I%d", signRow+6), name)
+	if nip := c.Query("approver_nip"); nip != "" {
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", signRow+7), "NIP. "+nip)
+	}
+
+	f.SetColWidth(sheet, "A", "A", 6)
+	f.SetColWidth(sheet, "B", "D", 22)
+	f.SetColWidth(sheet, "E", "K", 14)
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		fail(c, err)
+		return
+	}
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="honorarium_%s_%s.xlsx"`, start.Format("20060102"), end.Format("20060102")))
+	c.Writer.Write(buf.Bytes())
+}
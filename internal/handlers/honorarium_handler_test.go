@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPutRateReturnsStoredRate(t *testing.T) {
+	r, h := setupSchools(t)
+	r.PUT("/honorarium/rates", h.PutRate)
+	r.DELETE("/honorarium/rates/:id", h.DeleteRate)
+
+	nik := uuid.New().String()
+	put := func(body string) (id string, extra *float64) {
+		resp := call(r, http.MethodPut, "/honorarium/rates", defaultKey, body)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var out struct {
+			ID        string   `json:"id"`
+			ExtraRate *float64 `json:"extra_rate"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &out))
+		return out.ID, out.ExtraRate
+	}
+
+	id, extra := put(`{"teacher_nik": "` + nik + `", "regular_rate": 50000}`)
+	assert.NotEqual(t, primitive.NilObjectID.Hex(), id)
+	assert.Nil(t, extra)
+
+	again, extra := put(`{"teacher_nik": "` + nik + `", "regular_rate": 50000, "extra_rate": 0}`)
+	assert.Equal(t, id, again)
+	require.NotNil(t, extra)
+	assert.Equal(t, 0.0, *extra)
+
+	assert.Equal(t, http.StatusOK, call(r, http.MethodDelete, "/honorarium/rates/"+id, defaultKey, "").Code)
+}
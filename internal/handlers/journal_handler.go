@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/journal"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+type journalInput struct {
+	TeacherNIK     string `json:"teacher_nik" binding:"required"`
+	Status         string `json:"status" binding:"required"`
+	Topic          string `json:"topic"`
+	Note           string `json:"note"`
+	SubstituteNIK  string `json:"substitute_nik"`
+	SubstituteName string `json:"substitute_name"`
+}
+
+// entry checks in and turns it into the journal entry of lesson s.
+func (in journalInput) entry(s *models.Schedule) (*models.JournalEntry, error) {
+	var errs validate.Errors
+	if !models.ValidJournal(in.Status) {
+		errs.Add("status", validate.CodeUnknown, "must be delivered, cancelled or substituted, got %q", in.Status)
+	}
+	if in.Status != models.JournalCancelled && in.Topic == "" {
+		errs.Add("topic", validate.CodeRequired, "the topic taught is required")
+	}
+	if in.Status == models.JournalSubstituted {
+		switch in.SubstituteNIK {
+		case "":
+			errs.Add("substitute_nik", validate.CodeRequired, "is required for a substituted lesson")
+		case s.TeacherNIK:
+			errs.Add("substitute_nik", validate.CodeRange, "must differ from the lesson's teacher %s", s.TeacherNIK)
+		}
+	} else {
+		in.SubstituteNIK, in.SubstituteName = "", ""
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &models.JournalEntry{
+		ScheduleUUID:   s.UUID,
+		ClassCode:      s.ClassCode,
+		SubjectCode:    s.SubjectCode,
+		TeacherNIK:     s.TeacherNIK,
+		Date:           s.Date,
+		TermID:         s.TermID,
+		Status:         in.Status,
+		Topic:          in.Topic,
+		Note:           in.Note,
+		SubstituteNIK:  in.SubstituteNIK,
+		SubstituteName: in.SubstituteName,
+		RecordedBy:     in.TeacherNIK,
+	}, nil
+}
+
+// recordable reports whether in.TeacherNIK may record the journal of s:
+// its own teacher, or the substitute who took it.
+func (in journalInput) recordable(s *models.Schedule) bool {
+	return in.TeacherNIK == s.TeacherNIK ||
+		in.Status == models.JournalSubstituted && in.TeacherNIK == in.SubstituteNIK
+}
+
+// SubmitJournal records how a lesson went: delivered with its topic,
+// cancelled, or taken by a substitute. A lesson can be cancelled ahead of
+// time but only reported taught once it has started. Its term must not be
+// locked.
+func (h *Handler) SubmitJournal(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in journalInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	s, ok := h.findForChange(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+	if !in.recordable(s) {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden,
+			fmt.Sprintf("schedule %s is not taught by %s", s.UUID, in.TeacherNIK), nil)
+		return
+	}
+	if in.Status != models.JournalCancelled && !s.StartAt.IsZero() && s.StartAt.After(time.Now()) {
+		invalid(c, validate.Errors{{Field: "schedule_uuid", Code: validate.CodeRange, Message: "the lesson has not started yet"}})
+		return
+	}
+	if err := h.unlocked(s.Date); err != nil {
+		fail(c, err)
+		return
+	}
+	e, err := in.entry(s)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.Journal.Save(e); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// LessonJournal returns the journal entry of a lesson.
+func (h *Handler) LessonJournal(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	e, err := h.Journal.Get(c.Param("uuid"))
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			notFound(c, fmt.Sprintf("no journal entry for schedule %s", c.Param("uuid")))
+			return
+		}
+		fail(c, err)
+		return
+	}
+	s, err := h.Repo.FindByUUID(e.ScheduleUUID)
+	switch {
+	case err == nil:
+		*e = e.For(*s)
+	case !errors.Is(err, repo.ErrNotFound):
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// journalRows loads the lessons matching filter with their journal. With
+// a teacher nik they are the teacher's own lessons plus the lessons the
+// teacher took as a substitute.
+func (h *Handler) journalRows(filter bson.M, nik string) ([]models.Schedule, models.Journal, error) {
+	own := bson.M{}
+	for k, v := range filter {
+		own[k] = v
+	}
+	if nik != "" {
+		own["teacher_nik"] = nik
+	}
+	rows, err := h.lessons(own)
+	if err != nil {
+		return nil, nil, err
+	}
+	if nik != "" {
+		// The lessons are matched against filter, not the entries: their
+		// copies of the lesson's date and class may be stale.
+		entries, err := h.Journal.Find(bson.M{"substitute_nik": nik, "status": models.JournalSubstituted})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(entries) > 0 {
+			uuids := make([]string, len(entries))
+			for i, e := range entries {
+				uuids[i] = e.ScheduleUUID
+			}
+			taken := bson.M{"uuid": bson.M{"$in": uuids}}
+			for k, v := range filter {
+				taken[k] = v
+			}
+			more, err := h.lessons(taken)
+			if err != nil {
+				return nil, nil, err
+			}
+			rows = append(rows, more...)
+		}
+	}
+	j, err := h.Journal.Of(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	j.Sync(rows)
+	return rows, j, nil
+}
+
+func (h *Handler) lessons(filter bson.M) ([]models.Schedule, error) {
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(filter))
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// journalLesson is a lesson with its journal entry, nil while unrecorded.
+type journalLesson struct {
+	models.Schedule
+	Journal *models.JournalEntry `json:"journal"`
+}
+
+// ListJournal lists the lessons of a term or date range with their journal
+// entries and the planned against delivered JP. ?teacher_nik= narrows it to
+// what one teacher taught or was to teach, ?class_code= to one class.
+func (h *Handler) ListJournal(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	start, end, filter, ok := h.periodFilter(c)
+	if !ok {
+		return
+	}
+	if cc := c.Query("class_code"); cc != "" {
+		filter["class_code"] = cc
+	}
+	nik := c.Query("teacher_nik")
+	rows, j, err := h.journalRows(filter, nik)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	sort.Slice(rows, func(a, b int) bool {
+		if !rows[a].Date.Equal(rows[b].Date) {
+			return rows[a].Date.Before(rows[b].Date)
+		}
+		return rows[a].TimeStart < rows[b].TimeStart
+	})
+	lessons := make([]journalLesson, len(rows))
+	for i, s := range rows {
+		lessons[i].Schedule = s
+		if e, ok := j[s.UUID]; ok {
+			lessons[i].Journal = &e
+		}
+	}
+	totals := journal.ByTeacher(rows, h.JP, j)
+	resp := gin.H{"start_date": start, "end_date": end, "lessons": lessons}
+	if nik != "" {
+		resp["teacher_nik"], resp["total"] = nik, journal.Of(totals, nik)
+	} else {
+		resp["teachers"] = totals
+	}
+	c.JSON(http.StatusOK, resp)
+}
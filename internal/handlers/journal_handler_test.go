@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func TestJournalInputEntry(t *testing.T) {
+	lesson := &models.Schedule{UUID: "u1", ClassCode: "X-1", SubjectCode: "MTK", TeacherNIK: "111"}
+
+	in := journalInput{TeacherNIK: "111", Status: models.JournalDelivered, Topic: "Persamaan kuadrat", SubstituteNIK: "222"}
+	e, err := in.entry(lesson)
+	require.NoError(t, err)
+	assert.Equal(t, "X-1", e.ClassCode)
+	assert.Equal(t, "111", e.TeacherNIK)
+	assert.Equal(t, "111", e.RecordedBy)
+	assert.Empty(t, e.SubstituteNIK)
+
+	cancelled := journalInput{TeacherNIK: "111", Status: models.JournalCancelled}
+	_, err = cancelled.entry(lesson)
+	assert.NoError(t, err)
+
+	sub := journalInput{TeacherNIK: "222", Status: models.JournalSubstituted, Topic: "Latihan soal", SubstituteNIK: "222"}
+	assert.True(t, sub.recordable(lesson))
+	e, err = sub.entry(lesson)
+	require.NoError(t, err)
+	assert.Equal(t, "111", e.TeacherNIK)
+	assert.Equal(t, "222", e.SubstituteNIK)
+
+	other := journalInput{TeacherNIK: "333", Status: models.JournalDelivered, Topic: "x"}
+	assert.False(t, other.recordable(lesson))
+
+	bad := journalInput{TeacherNIK: "111", Status: models.JournalSubstituted, SubstituteNIK: "111"}
+	_, err = bad.entry(lesson)
+	var fields validate.Errors
+	require.True(t, errors.As(err, &fields))
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "topic", fields[0].Field)
+	assert.Equal(t, "substitute_nik", fields[1].Field)
+}
@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/payroll"
+)
+
+func TestSubmitJournalPaysSubstitute(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/schedules", h.Create)
+	r.PUT("/schedules/:uuid/journal", h.SubmitJournal)
+	r.POST("/schedules/:uuid/move", h.Move)
+	r.GET("/honorarium", h.Honorarium)
+	r.PUT("/honorarium/rates", h.PutRate)
+	r.DELETE("/honorarium/rates/:id", h.DeleteRate)
+
+	nik, sub := newNIK(), newNIK()
+	past := createLesson(t, r, nik, "2024-03-05", "07:00:00", "08:30:00")
+	future := createLesson(t, r, nik, "2031-03-04", "07:00:00", "08:30:00")
+	submit := func(lesson, body string) int {
+		return call(r, http.MethodPut, "/schedules/"+lesson+"/journal", defaultKey, body).Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, submit(past, `{"teacher_nik": "`+newNIK()+`", "status": "delivered", "topic": "Limit"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, submit(future, `{"teacher_nik": "`+nik+`", "status": "delivered", "topic": "Limit"}`))
+	assert.Equal(t, http.StatusOK, submit(future, `{"teacher_nik": "`+nik+`", "status": "cancelled"}`))
+	require.Equal(t, http.StatusOK, submit(past, `{"teacher_nik": "`+sub+`", "status": "substituted", "topic": "Latihan soal", "substitute_nik": "`+sub+`"}`))
+
+	resp := call(r, http.MethodPut, "/honorarium/rates", defaultKey, `{"teacher_nik": "`+sub+`", "regular_rate": 50000, "substitute_rate": 40000}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var rate struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &rate))
+	t.Cleanup(func() { call(r, http.MethodDelete, "/honorarium/rates/"+rate.ID, defaultKey, "") })
+
+	var pay struct {
+		Lines []payroll.Line `json:"lines"`
+	}
+	resp = call(r, http.MethodGet, "/honorarium?start_date=2024-03-05&end_date=2024-03-05&teacher_nik="+sub, defaultKey, "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &pay))
+	require.Len(t, pay.Lines, 1)
+	assert.Equal(t, sub, pay.Lines[0].TeacherNIK)
+	assert.Positive(t, pay.Lines[0].SubstituteJP)
+	assert.Equal(t, pay.Lines[0].SubstituteJP*40000, pay.Lines[0].Amount)
+
+	// The lesson's own teacher is not paid for it.
+	resp = call(r, http.MethodGet, "/honorarium?start_date=2024-03-05&end_date=2024-03-05&teacher_nik="+nik, defaultKey, "")
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &pay))
+	assert.Empty(t, pay.Lines)
+
+	// Moving the lesson moves the substitute's pay with it.
+	resp = call(r, http.MethodPost, "/schedules/"+past+"/move", defaultKey, `{"date": "2024-03-12", "jam_ke": 1, "time_start": "07:00", "time_end": "08:30"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	resp = call(r, http.MethodGet, "/honorarium?start_date=2024-03-05&end_date=2024-03-05&teacher_nik="+sub, defaultKey, "")
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &pay))
+	assert.Empty(t, pay.Lines)
+	resp = call(r, http.MethodGet, "/honorarium?start_date=2024-03-12&end_date=2024-03-12&teacher_nik="+sub, defaultKey, "")
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &pay))
+	require.Len(t, pay.Lines, 1)
+	assert.Equal(t, sub, pay.Lines[0].TeacherNIK)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/availability"
+	"github.com/mghazyfawazh/EGS/internal/bell"
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// slotTimes looks up the times of period jamKe on date in the bell
+// schedule.
+func (h *Handler) slotTimes(date time.Time, jamKe int) (string, string, bool, error) {
+	ws := calendar.WeekStart(date)
+	periods, err := h.bellSchedule(ws, ws.AddDate(0, 0, 6))
+	if err != nil {
+		return "", "", false, err
+	}
+	p, ok := bell.Find(periods, availability.ISOWeekday(date), jamKe)
+	return p.TimeStart, p.TimeEnd, ok, nil
+}
+
+// slotUpdate stamps s and returns the update moving a lesson into its slot.
+func (h *Handler) slotUpdate(s *models.Schedule) (bson.M, error) {
+	if err := h.stamp(s); err != nil {
+		return nil, err
+	}
+	return bson.M{
+		"updated_at": time.Now(),
+		"date":       s.Date,
+		"jam_ke":     s.JamKe,
+		"time_start": s.TimeStart,
+		"time_end":   s.TimeEnd,
+		"start_at":   s.StartAt,
+		"end_at":     s.EndAt,
+		"term_id":    s.TermID,
+	}, nil
+}
+
+// moveTarget returns s moved to period jamKe, on date unless that is empty.
+// Without timeStart/timeEnd the times come from the bell schedule. Bad
+// parameters are returned as validate.Errors.
+func (h *Handler) moveTarget(s *models.Schedule, date string, jamKe int, timeStart, timeEnd string) (models.Schedule, error) {
+	moved := *s
+	moved.JamKe = jamKe
+	if date != "" {
+		d, err := h.parseDate(date)
+		if err != nil {
+			return moved, validate.Errors{{Field: "date", Code: validate.CodeFormat, Message: "must be YYYY-MM-DD"}}
+		}
+		moved.Date = d
+	}
+	if (timeStart == "") != (timeEnd == "") {
+		return moved, validate.Errors{{Field: "time_end", Code: validate.CodeRequired,
+			Message: "time_start and time_end must be given together"}}
+	}
+	if timeStart != "" {
+		moved.TimeStart, moved.TimeEnd = timeStart, timeEnd
+		return moved, nil
+	}
+	ts, te, found, err := h.slotTimes(moved.Date, moved.JamKe)
+	if err != nil {
+		return moved, err
+	}
+	if !found {
+		return moved, validate.Errors{{Field: "jam_ke", Code: validate.CodeRange,
+			Message: fmt.Sprintf("period %d is not in the bell schedule of that day; pass time_start and time_end", moved.JamKe)}}
+	}
+	moved.TimeStart, moved.TimeEnd = ts, te
+	return moved, nil
+}
+
+func (h *Handler) findForChange(c *gin.Context, uuid string) (*models.Schedule, bool) {
+	s, err := h.Repo.FindByUUID(uuid)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			notFound(c, fmt.Sprintf("schedule %s not found", uuid))
+			return nil, false
+		}
+		fail(c, err)
+		return nil, false
+	}
+	return s, true
+}
+
+// Move puts a lesson into another period, optionally on another date.
+// Without time_start/time_end the times come from the bell schedule.
+func (h *Handler) Move(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Date      string `json:"date"`
+		JamKe     int    `json:"jam_ke" binding:"required"`
+		TimeStart string `json:"time_start"`
+		TimeEnd   string `json:"time_end"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	s, ok := h.findForChange(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	moved, err := h.moveTarget(s, in.Date, in.JamKe, in.TimeStart, in.TimeEnd)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	if err := h.writable(s.Date, moved.Date); err != nil {
+		fail(c, err)
+		return
+	}
+	rules, err := h.rules()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if errs := rules.Schedule(moved); len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+
+	reason, err := h.CheckSlot(moved.Date, moved.ClassCode, moved.TeacherNIK, moved.JamKe, moved.TimeStart, moved.TimeEnd, moved.UUID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if reason != "" {
+		scheduleConflict(c, reason)
+		return
+	}
+	warnings, err := h.CheckWorkload(moved)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if len(warnings) > 0 && h.blocksWorkload() {
+		workloadExceeded(c, strings.Join(warnings, "; "))
+		return
+	}
+
+	update, err := h.slotUpdate(&moved)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.Repo.UpdateByUUID(moved.UUID, update); err != nil {
+		fail(c, err)
+		return
+	}
+	moved.UpdatedAt = update["updated_at"].(time.Time)
+	moved.Warnings = warnings
+	h.emit(events.Updated, &moved, s)
+	c.JSON(http.StatusOK, moved)
+}
+
+// Swap exchanges the slots (date, jam_ke and times) of two lessons. Both
+// final positions are validated together, ignoring where the two lessons
+// are now, and saved in one transaction.
+func (h *Handler) Swap(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		UUIDA string `json:"uuid_a" binding:"required"`
+		UUIDB string `json:"uuid_b" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	if in.UUIDA == in.UUIDB {
+		invalid(c, validate.Errors{{Field: "uuid_b", Code: validate.CodeRange, Message: "must differ from uuid_a"}})
+		return
+	}
+	a, ok := h.findForChange(c, in.UUIDA)
+	if !ok {
+		return
+	}
+	b, ok := h.findForChange(c, in.UUIDB)
+	if !ok {
+		return
+	}
+	swapped, warnings, err := h.swap(a, b)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp := gin.H{"message": "swapped", "schedules": swapped}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// swap exchanges the slots of a and b, shared by Swap and approved change
+// requests. It returns both lessons as saved and any workload warnings.
+func (h *Handler) swap(a, b *models.Schedule) ([]models.Schedule, []string, error) {
+	newA, newB, warnings, err := h.checkSwap(a, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	updA, err := h.slotUpdate(&newA)
+	if err != nil {
+		return nil, nil, err
+	}
+	updB, err := h.slotUpdate(&newB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := h.Repo.UpdateManyByUUID(map[string]bson.M{a.UUID: updA, b.UUID: updB}); err != nil {
+		return nil, nil, err
+	}
+	newA.UpdatedAt = updA["updated_at"].(time.Time)
+	newB.UpdatedAt = updB["updated_at"].(time.Time)
+	h.emit(events.Updated, &newA, a)
+	h.emit(events.Updated, &newB, b)
+	return []models.Schedule{newA, newB}, warnings, nil
+}
+
+// checkSwap validates both final positions of a swap together, ignoring
+// where the two lessons are now, without saving anything.
+func (h *Handler) checkSwap(a, b *models.Schedule) (models.Schedule, models.Schedule, []string, error) {
+	newA, newB := *a, *b
+	newA.Date, newA.JamKe, newA.TimeStart, newA.TimeEnd = b.Date, b.JamKe, b.TimeStart, b.TimeEnd
+	newB.Date, newB.JamKe, newB.TimeStart, newB.TimeEnd = a.Date, a.JamKe, a.TimeStart, a.TimeEnd
+	if err := h.writable(a.Date, b.Date); err != nil {
+		return newA, newB, nil, err
+	}
+
+	for _, s := range []models.Schedule{newA, newB} {
+		reason, err := h.CheckSlot(s.Date, s.ClassCode, s.TeacherNIK, s.JamKe, s.TimeStart, s.TimeEnd, a.UUID, b.UUID)
+		if err != nil {
+			return newA, newB, nil, err
+		}
+		if reason != "" {
+			return newA, newB, nil, slotConflictError(fmt.Sprintf("%s: %s", s.UUID, reason))
+		}
+	}
+	// The two lessons against each other in their new slots.
+	if newA.Date.Equal(newB.Date) && (newA.ClassCode == newB.ClassCode || newA.TeacherNIK == newB.TeacherNIK) {
+		overlap, err := timeOverlap(newA.TimeStart, newA.TimeEnd, newB.TimeStart, newB.TimeEnd)
+		if err != nil {
+			return newA, newB, nil, err
+		}
+		if overlap {
+			return newA, newB, nil, slotConflictError("the swapped lessons overlap each other")
+		}
+	}
+	warnings, err := h.CheckWorkload(newA, newB)
+	if err != nil {
+		return newA, newB, nil, err
+	}
+	if len(warnings) > 0 && h.blocksWorkload() {
+		return newA, newB, nil, workloadError(strings.Join(warnings, "; "))
+	}
+	return newA, newB, warnings, nil
+}
@@ -0,0 +1,35 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveAndSwapReportFieldErrors(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/schedules", h.Create)
+	r.POST("/schedules/swap", h.Swap)
+	r.POST("/schedules/:uuid/move", h.Move)
+
+	lesson := createLesson(t, r, newNIK(), "2031-04-07", "07:00:00", "08:30:00")
+	for _, tc := range []struct{ path, body, field string }{
+		{"/schedules/" + lesson + "/move", `{"date": "2031-04-08"}`, "jam_ke"},
+		{"/schedules/" + lesson + "/move", `{"date": "08-04-2031", "jam_ke": 2, "time_start": "09:00", "time_end": "10:30"}`, "date"},
+		{"/schedules/swap", `{"uuid_a": "` + lesson + `", "uuid_b": "` + lesson + `"}`, "uuid_b"},
+	} {
+		resp := call(r, http.MethodPost, tc.path, defaultKey, tc.body)
+		require.Equal(t, http.StatusUnprocessableEntity, resp.Code, resp.Body.String())
+		var p struct {
+			Fields []struct {
+				Field string `json:"field"`
+			} `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &p))
+		require.NotEmpty(t, p.Fields)
+		assert.Equal(t, tc.field, p.Fields[0].Field)
+	}
+}
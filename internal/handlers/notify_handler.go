@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/notify"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"github.com/mghazyfawazh/EGS/internal/tz"
+)
+
+// Notifier returns the notifier that emails teachers about changes
+// published on h.Events.
+func (h *Handler) Notifier() *notify.Notifier {
+	return &notify.Notifier{
+		Sender: h.Sender,
+		Teacher: func(school, nik string) (*models.Teacher, error) {
+			t, err := h.Teachers.For(school).FindByNIK(nik)
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil, nil
+			}
+			return t, err
+		},
+		Horizon: time.Duration(h.Cfg.NotifyHorizonDays) * 24 * time.Hour,
+	}
+}
+
+// digestResult reports a digest run.
+type digestResult struct {
+	Date         time.Time `json:"date"`
+	Sent         int       `json:"sent"`
+	WithoutEmail []string  `json:"without_email"`
+	// Failed lists the teachers the digest could not be sent to.
+	Failed []string `json:"failed"`
+	// sentTo lists the teachers it went to.
+	sentTo []string
+}
+
+// sendDigest emails every teacher with lessons on day the list of them,
+// skipping the teachers in done. Teachers without a profile email are
+// reported, not mailed. A failed send does not stop the others; the
+// failures are returned together.
+func (h *Handler) sendDigest(day time.Time, done []string) (digestResult, error) {
+	res := digestResult{Date: day, WithoutEmail: []string{}, Failed: []string{}}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(bson.M{"date": day}))
+	if err != nil {
+		return res, err
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return res, err
+	}
+	byTeacher := map[string][]models.Schedule{}
+	for _, s := range rows {
+		byTeacher[s.TeacherNIK] = append(byTeacher[s.TeacherNIK], s)
+	}
+	for _, nik := range done {
+		delete(byTeacher, nik)
+	}
+	teachers, err := h.Teachers.FindAll()
+	if err != nil {
+		return res, err
+	}
+	profiles := map[string]models.Teacher{}
+	for _, t := range teachers {
+		profiles[t.NIK] = t
+	}
+
+	niks := make([]string, 0, len(byTeacher))
+	for nik := range byTeacher {
+		niks = append(niks, nik)
+	}
+	sort.Strings(niks)
+	var errs []error
+	for _, nik := range niks {
+		t, ok := profiles[nik]
+		if !ok || t.Email == "" {
+			res.WithoutEmail = append(res.WithoutEmail, nik)
+			continue
+		}
+		if err := h.Sender.Send(notify.DigestMessage(t, day, byTeacher[nik])); err != nil {
+			res.Failed = append(res.Failed, nik)
+			errs = append(errs, fmt.Errorf("teacher %s: %w", nik, err))
+			continue
+		}
+		res.Sent++
+		res.sentTo = append(res.sentTo, nik)
+	}
+	return res, errors.Join(errs...)
+}
+
+// SendDigest sends the digest of ?date= (default tomorrow) to the
+// school's teachers now, whether or not it already went out.
+func (h *Handler) SendDigest(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	day := h.today().AddDate(0, 0, 1)
+	if v := c.Query("date"); v != "" {
+		d, err := h.parseDate(v)
+		if err != nil {
+			badRequest(c, "date must be YYYY-MM-DD")
+			return
+		}
+		day = d
+	}
+	res, err := h.sendDigest(day, nil)
+	if err != nil && res.Sent == 0 && len(res.Failed) == 0 {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// digestLease is how long a server has to send a digest before another
+// may take it over.
+const digestLease = 10 * time.Minute
+
+// RunDigests sends each school its digest of the next day once the
+// school's clock reaches NotifyDigestHour, until ctx ends. Teachers a
+// send failed for are retried each minute of that hour.
+func (h *Handler) RunDigests(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, school := range tenant.FromEnv().Schools() {
+			hs := h.forSchool(school)
+			if time.Now().In(hs.Zone).Hour() != hs.Cfg.NotifyDigestHour {
+				continue
+			}
+			day := tz.Today(hs.Zone).AddDate(0, 0, 1)
+			sent, ok, err := hs.Digests.Claim(day, digestLease)
+			if err != nil {
+				log.Printf("digest of %s: %v", school, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			res, err := hs.sendDigest(day, sent)
+			if ferr := hs.Digests.Finish(day, res.sentTo, err == nil); ferr != nil {
+				log.Printf("digest of %s: %v", school, ferr)
+			}
+			if err != nil {
+				log.Printf("digest of %s for %s: %d sent, retrying the rest: %v",
+					school, day.Format("2006-01-02"), res.Sent, err)
+				continue
+			}
+			log.Printf("digest of %s for %s: %d sent, %d teachers without email",
+				school, day.Format("2006-01-02"), res.Sent, len(res.WithoutEmail))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/notify"
+)
+
+// failingSender fails for one address and records the others.
+type failingSender struct {
+	fail string
+	sent []string
+}
+
+func (s *failingSender) Send(m notify.Message) error {
+	if m.To == s.fail {
+		return errors.New("smtp: 451 try again later")
+	}
+	s.sent = append(s.sent, m.To)
+	return nil
+}
+
+func TestSendDigestContinuesAfterFailedSend(t *testing.T) {
+	r, h := setupSchools(t)
+	r.POST("/notifications/digest", h.SendDigest)
+
+	day := time.Date(2031, 3, 3, 0, 0, 0, 0, time.UTC)
+	bad, good := uuid.New().String(), uuid.New().String()
+	for _, nik := range []string{bad, good} {
+		require.NoError(t, h.Teachers.For("sma2").Upsert(&models.Teacher{NIK: nik, Name: nik, Email: nik + "@sekolah.id"}))
+		require.NoError(t, h.Repo.For("sma2").Insert(&models.Schedule{
+			UUID: uuid.New().String(), ClassCode: "X-1", SubjectCode: "MTK", TeacherNIK: nik,
+			Date: day, JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00",
+		}))
+	}
+	sender := &failingSender{fail: bad + "@sekolah.id"}
+	h.Sender = sender
+
+	resp := call(r, http.MethodPost, "/notifications/digest?date=2031-03-03", otherKey, "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var res struct {
+		Sent   int      `json:"sent"`
+		Failed []string `json:"failed"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	assert.Equal(t, []string{bad}, res.Failed)
+	assert.Contains(t, sender.sent, good+"@sekolah.id")
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/mghazyfawazh/EGS/internal/patch"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+)
+
+// Patch changes part of a lesson. The body is a JSON Merge Patch
+// (application/merge-patch+json, also accepted as application/json) or a
+// JSON Patch (application/json-patch+json) against the lesson as returned
+// by GET, restricted to the fields PUT accepts. The patched lesson must
+// pass the same validation as PUT; setting a required field to null is
+// therefore a 422 while lesson_type can be cleared.
+func (h *Handler) Patch(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	ct, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		ct = ""
+	}
+	var apply func(doc, p []byte) ([]byte, error)
+	switch ct {
+	case patch.MergePatchType, "application/json":
+		apply = patch.Merge
+	case patch.JSONPatchType:
+		apply = patch.Apply
+	default:
+		problem.Write(c, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+			"Content-Type must be "+patch.MergePatchType+" or "+patch.JSONPatchType, nil)
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	existing, err := h.Repo.FindByUUID(c.Param("uuid"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	doc, err := json.Marshal(inputOf(existing))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	patched, err := apply(doc, body)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	var in scheduleInput
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		badRequest(c, "patched schedule is invalid: "+err.Error())
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	h.replace(c, existing, in)
+}
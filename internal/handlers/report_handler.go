@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/report"
+)
+
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Report builds a JP recap for an arbitrary grouping, e.g.
+// ?group_by=class,subject&measures=lessons,jp&start_date=..&end_date=..&format=xlsx
+func (h *Handler) Report(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	sd := c.Query("start_date")
+	ed := c.Query("end_date")
+	if sd == "" || ed == "" {
+		badRequest(c, "start_date and end_date required")
+		return
+	}
+	start, err := h.parseDate(sd)
+	if err != nil {
+		badRequest(c, "invalid start_date")
+		return
+	}
+	end, err := h.parseDate(ed)
+	if err != nil {
+		badRequest(c, "invalid end_date")
+		return
+	}
+
+	q := report.Query{
+		Start:      start,
+		End:        end,
+		Dimensions: splitList(c.Query("group_by")),
+		Measures:   splitList(c.Query("measures")),
+		Match:      h.Repo.Scope(nil),
+	}
+	for _, f := range []string{"teacher_nik", "class_code", "subject_code"} {
+		if v := c.Query(f); v != "" {
+			q.Match[f] = v
+		}
+	}
+	if err := q.Validate(); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" {
+		badRequest(c, "format must be json, csv or xlsx")
+		return
+	}
+
+	table, err := report.Run(h.Ctx, h.Coll, h.JP, q)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("rekap_%s_%s_%s", strings.Join(q.Dimensions, "_"), start.Format("20060102"), end.Format("20060102"))
+	var buf bytes.Buffer
+	switch format {
+	case "csv":
+		if err := report.WriteCSV(&buf, table); err != nil {
+			fail(c, err)
+			return
+		}
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		c.Writer.Write(buf.Bytes())
+	case "xlsx":
+		if err := report.WriteXLSX(&buf, "Rekap", table); err != nil {
+			fail(c, err)
+			return
+		}
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+		c.Writer.Write(buf.Bytes())
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"group_by": q.Dimensions,
+			"measures": q.Measures,
+			"rows":     table.Records(),
+		})
+	}
+}
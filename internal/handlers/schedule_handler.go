@@ -3,9 +3,10 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,55 +19,173 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"github.com/mghazyfawazh/EGS/internal/availability"
+	"github.com/mghazyfawazh/EGS/internal/bulk"
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/config"
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/journal"
 	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/notify"
+	"github.com/mghazyfawazh/EGS/internal/problem"
 	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"github.com/mghazyfawazh/EGS/internal/tz"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+	"github.com/mghazyfawazh/EGS/internal/webhook"
+	"github.com/mghazyfawazh/EGS/internal/workload"
 )
 
 type Handler struct {
 	Repo *repo.MongoRepo
 	Coll *mongo.Collection
 	Ctx  context.Context
+	Cfg  config.Config
+	JP   jp.Calculator
+	// Zone is the school's time zone, see package tz.
+	Zone *time.Location
+	// School is the tenant the handler acts for; see forSchool.
+	School string
+
+	Teachers     *repo.TeacherRepo
+	Rates        *repo.RateRepo
+	Availability *repo.AvailabilityRepo
+	Curriculum   *repo.CurriculumRepo
+	Bell         *repo.BellRepo
+	Holidays     *repo.HolidayRepo
+	Audit        *repo.AuditRepo
+	Schools      *repo.SchoolRepo
+	Terms        *repo.TermRepo
+	Drafts       *repo.DraftRepo
+	// Changes holds teachers' change requests awaiting review.
+	Changes *repo.ChangeRequestRepo
+	// Events carries schedule changes to subscribers; see package events.
+	Events *events.Bus
+	// Webhooks and the Dispatcher sending their deliveries.
+	Webhooks   *repo.WebhookRepo
+	Dispatcher *webhook.Dispatcher
+	// Sender delivers teacher notifications; Digests records the daily
+	// digests sent.
+	Sender  notify.Sender
+	Digests *repo.DigestRepo
+	// Attendance holds students' attendance per lesson.
+	Attendance *repo.AttendanceRepo
+	// Journal records how each lesson actually went.
+	Journal *repo.JournalRepo
+
+	// terms caches the school's terms for one request, see loadTerms.
+	terms       []models.Term
+	termsLoaded bool
+	// draft is the open draft the request edits and published the handler
+	// of the published lessons, see inDraft.
+	draft        *models.Draft
+	published    *Handler
+	openDrafts   []models.Draft
+	draftsLoaded bool
+	// bulkKey signs the confirm tokens of bulk operations, see bulk.Token.
+	bulkKey []byte
 }
 
+// NewHandler wires the handler around the schedules collection. The other
+// collections live in the same database.
 func NewHandler(r *repo.MongoRepo, coll *mongo.Collection) *Handler {
-	return &Handler{Repo: r, Coll: coll, Ctx: context.Background()}
+	cfg := config.LoadConfig()
+	zone, err := tz.Load(cfg.SchoolTimezone)
+	if err != nil {
+		log.Printf("SCHOOL_TZ: %v; using %s", err, zone)
+	}
+	if !jp.ValidRounding(cfg.JPRounding) {
+		log.Printf("JP_ROUNDING: unknown rounding %q; using %s", cfg.JPRounding, jp.RoundNearest)
+		cfg.JPRounding = jp.RoundNearest
+	}
+	bulkKey := []byte(cfg.BulkTokenKey)
+	if len(bulkKey) == 0 {
+		bulkKey = bulk.NewKey()
+	}
+	db := coll.Database()
+	webhooks := repo.NewWebhookRepo(db.Collection("webhooks"), db.Collection("webhook_deliveries"))
+	dispatcher := webhook.NewDispatcher(webhooks, cfg.WebhookMaxAttempts,
+		time.Duration(cfg.WebhookBackoffSeconds)*time.Second, time.Duration(cfg.WebhookBackoffMaxSeconds)*time.Second)
+	return &Handler{
+		Repo:   r,
+		Coll:   coll,
+		Ctx:    context.Background(),
+		Cfg:    cfg,
+		JP:     jp.Calculator{LessonMinutes: cfg.JPMinutes, Rounding: cfg.JPRounding},
+		Zone:   zone,
+		School: tenant.Default,
+
+		Teachers:     repo.NewTeacherRepo(db.Collection("teachers")),
+		Rates:        repo.NewRateRepo(db.Collection("honorarium_rates")),
+		Availability: repo.NewAvailabilityRepo(db.Collection("teacher_availability"), db.Collection("teacher_unavailability")),
+		Curriculum:   repo.NewCurriculumRepo(db.Collection("curriculum_requirements")),
+		Bell:         repo.NewBellRepo(db.Collection("bell_schedule")),
+		Holidays:     repo.NewHolidayRepo(db.Collection("holidays")),
+		Audit:        repo.NewAuditRepo(db.Collection("audit_log")),
+		Schools:      repo.NewSchoolRepo(db.Collection("schools")),
+		Terms:        repo.NewTermRepo(db.Collection("academic_years"), db.Collection("terms")),
+		Drafts:       repo.NewDraftRepo(db.Collection("drafts"), db.Collection("draft_schedules")),
+		Changes:      repo.NewChangeRequestRepo(db.Collection("change_requests")),
+		Events:       events.NewBus(),
+		Webhooks:     webhooks,
+		Dispatcher:   dispatcher,
+		Sender:       notify.NewSender(cfg),
+		Digests:      repo.NewDigestRepo(db.Collection("digest_runs")),
+		Attendance:   repo.NewAttendanceRepo(db.Collection("attendance")),
+		Journal:      repo.NewJournalRepo(db.Collection("teaching_journal")),
+		bulkKey:      bulkKey,
+	}
 }
 
 func BsonE(key string, value interface{}) primitive.E {
 	return primitive.E{Key: key, Value: value}
 }
 
+// authorize accepts requests whose school the API key middleware already
+// resolved, and otherwise resolves the x-api-key header itself.
 func authorize(c *gin.Context) bool {
-	expected := os.Getenv("API_KEY")
-	if expected == "" {
-		expected = "SECRET123"
+	if c.GetString(tenant.Key) != "" {
+		return true
 	}
-	got := c.GetHeader("x-api-key")
-	if got == "" || got != expected {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	school, ok := tenant.FromEnv().Resolve(c.GetHeader("x-api-key"))
+	if !ok {
+		problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid x-api-key", nil)
 		return false
 	}
+	c.Set(tenant.Key, school)
 	return true
 }
 
-func parseDate(dateStr string) (time.Time, error) {
-	return time.Parse("2006-01-02", dateStr)
+// authorizeAdmin is authorize for administrators: the x-api-key header
+// must be an admin key of the request's school.
+func authorizeAdmin(c *gin.Context) bool {
+	if !authorize(c) {
+		return false
+	}
+	if school, ok := tenant.AdminFromEnv().Resolve(c.GetHeader("x-api-key")); !ok || school != tenant.From(c) {
+		problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "an admin key (SCHOOL_ADMIN_KEYS) is required", nil)
+		return false
+	}
+	return true
 }
 
+// timeOverlap compares the wall-clock times of two lessons on the same day.
+// Both are in the school's zone, so the clock readings compare directly.
 func timeOverlap(s1, e1, s2, e2 string) (bool, error) {
-	t1s, err := time.Parse("15:04:05", s1)
+	t1s, err := jp.ParseClock(s1)
 	if err != nil {
 		return false, err
 	}
-	t1e, err := time.Parse("15:04:05", e1)
+	t1e, err := jp.ParseClock(e1)
 	if err != nil {
 		return false, err
 	}
-	t2s, err := time.Parse("15:04:05", s2)
+	t2s, err := jp.ParseClock(s2)
 	if err != nil {
 		return false, err
 	}
-	t2e, err := time.Parse("15:04:05", e2)
+	t2e, err := jp.ParseClock(e2)
 	if err != nil {
 		return false, err
 	}
@@ -82,9 +201,9 @@ func timeOverlap(s1, e1, s2, e2 string) (bool, error) {
 	return false, nil
 }
 
-func (h *Handler) DetectConflict(date time.Time, classCode, teacherNIK, timeStart, timeEnd, excludeUUID string) (bool, error) {
+func (h *Handler) DetectConflict(date time.Time, classCode, teacherNIK, timeStart, timeEnd string, excludeUUIDs ...string) (bool, error) {
 	filter := bson.M{"date": date}
-	cur, err := h.Coll.Find(h.Ctx, filter)
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(filter))
 	if err != nil {
 		return false, err
 	}
@@ -93,7 +212,7 @@ func (h *Handler) DetectConflict(date time.Time, classCode, teacherNIK, timeStar
 		return false, err
 	}
 	for _, s := range rows {
-		if excludeUUID != "" && s.UUID == excludeUUID {
+		if excluded(s.UUID, excludeUUIDs) {
 			continue
 		}
 		if s.ClassCode == classCode || s.TeacherNIK == teacherNIK {
@@ -109,43 +228,171 @@ func (h *Handler) DetectConflict(date time.Time, classCode, teacherNIK, timeStar
 	return false, nil
 }
 
-func (h *Handler) Create(c *gin.Context) {
-	if !authorize(c) {
-		return
+func excluded(uuid string, excludeUUIDs []string) bool {
+	for _, x := range excludeUUIDs {
+		if x != "" && x == uuid {
+			return true
+		}
 	}
-	var in struct {
-		ClassCode   string `json:"class_code" binding:"required"`
-		ClassName   string `json:"class_name" binding:"required"`
-		SubjectCode string `json:"subject_code" binding:"required"`
-		TeacherNIK  string `json:"teacher_nik" binding:"required"`
-		TeacherName string `json:"teacher_name" binding:"required"`
-		Date        string `json:"date" binding:"required"` 
-		JamKe       int    `json:"jam_ke" binding:"required"`
-		TimeStart   string `json:"time_start" binding:"required"` 
-		TimeEnd     string `json:"time_end" binding:"required"`
+	return false
+}
+
+// CheckSlot validates a lesson slot as a whole: it must not overlap other
+// lessons of the class or teacher and the teacher must be available. It
+// returns the reason the slot is rejected, or "" when it is free.
+func (h *Handler) CheckSlot(date time.Time, classCode, teacherNIK string, jamKe int, timeStart, timeEnd string, excludeUUIDs ...string) (string, error) {
+	conflict, err := h.DetectConflict(date, classCode, teacherNIK, timeStart, timeEnd, excludeUUIDs...)
+	if err != nil {
+		return "", err
 	}
-	if err := c.ShouldBindJSON(&in); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if conflict {
+		return "overlaps another lesson of the class or teacher", nil
+	}
+
+	windows, err := h.Availability.WindowsOf(teacherNIK)
+	if err != nil {
+		return "", err
 	}
-	date, err := parseDate(in.Date)
+	leaves, err := h.Availability.LeavesOf(teacherNIK, date, date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format (expected YYYY-MM-DD)"})
-		return
+		return "", err
+	}
+	return availability.Check(windows, leaves, date, jamKe), nil
+}
+
+// CheckWorkload returns the workload maximums exceeded once the changed
+// lessons are saved. A changed lesson replaces the stored lesson with the
+// same UUID, so new, updated and moved lessons can all be checked, also
+// several at once.
+func (h *Handler) CheckWorkload(changed ...models.Schedule) ([]string, error) {
+	replaced := map[string]bool{}
+	for _, l := range changed {
+		replaced[l.UUID] = true
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	for _, lesson := range changed {
+		rows, err := h.teacherWeek(lesson.TeacherNIK, calendar.WeekStart(lesson.Date))
+		if err != nil {
+			return nil, err
+		}
+		week := rows[:0]
+		for _, r := range rows {
+			if !replaced[r.UUID] {
+				week = append(week, r)
+			}
+		}
+		for _, l := range changed {
+			if l.TeacherNIK == lesson.TeacherNIK {
+				week = append(week, l)
+			}
+		}
+
+		profile, err := h.Teachers.FindByNIK(lesson.TeacherNIK)
+		if err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return nil, err
+		}
+		limits := workload.For(profile, h.defaultLimits())
+		weekJP, dayJP := workload.Load(week, h.JP, lesson.Date)
+		for _, msg := range limits.Exceeded(weekJP, dayJP) {
+			if len(changed) > 1 {
+				msg = fmt.Sprintf("%s: %s", lesson.TeacherNIK, msg)
+			}
+			if !seen[msg] {
+				seen[msg] = true
+				out = append(out, msg)
+			}
+		}
 	}
+	return out, nil
+}
 
-	conflict, err := h.DetectConflict(date, in.ClassCode, in.TeacherNIK, in.TimeStart, in.TimeEnd, "")
+// teacherWeek returns the lessons of nik in the week starting ws. In a
+// draft, the days of the week outside the draft's range come from the
+// published lessons.
+func (h *Handler) teacherWeek(nik string, ws time.Time) ([]models.Schedule, error) {
+	filter := bson.M{"teacher_nik": nik, "date": bson.M{"$gte": ws, "$lt": ws.AddDate(0, 0, 7)}}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(filter))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
-	if conflict {
-		c.JSON(http.StatusConflict, gin.H{"error": "schedule conflict detected"})
-		return
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return nil, err
 	}
-	now := time.Now()
-	s := &models.Schedule{
-		UUID:        uuid.New().String(),
+	if h.draft == nil || !ws.Before(h.draft.StartDate) && !ws.AddDate(0, 0, 6).After(h.draft.EndDate) {
+		return rows, nil
+	}
+	p := h.published
+	filter["$nor"] = bson.A{bson.M{"date": bson.M{"$gte": h.draft.StartDate, "$lte": h.draft.EndDate}}}
+	cur, err = p.Coll.Find(p.Ctx, p.Repo.Scope(filter))
+	if err != nil {
+		return nil, err
+	}
+	var outside []models.Schedule
+	if err := cur.All(p.Ctx, &outside); err != nil {
+		return nil, err
+	}
+	return append(rows, outside...), nil
+}
+
+func (h *Handler) defaultLimits() workload.Limits {
+	return workload.Limits{
+		MinWeeklyJP: h.Cfg.WorkloadMinWeeklyJP,
+		MaxWeeklyJP: h.Cfg.WorkloadMaxWeeklyJP,
+		MaxDailyJP:  h.Cfg.WorkloadMaxDailyJP,
+	}
+}
+
+// blocksWorkload reports whether exceeded workload limits reject a change
+// instead of only warning about it.
+func (h *Handler) blocksWorkload() bool {
+	return h.Cfg.WorkloadMode == "block"
+}
+
+// scheduleInput is the full, writable representation of a lesson as sent
+// to Create and PUT, and as seen by PATCH documents.
+type scheduleInput struct {
+	ClassCode   string `json:"class_code" binding:"required"`
+	ClassName   string `json:"class_name" binding:"required"`
+	SubjectCode string `json:"subject_code" binding:"required"`
+	TeacherNIK  string `json:"teacher_nik" binding:"required"`
+	TeacherName string `json:"teacher_name" binding:"required"`
+	Date        string `json:"date" binding:"required"`
+	JamKe       int    `json:"jam_ke" binding:"required"`
+	TimeStart   string `json:"time_start" binding:"required"`
+	TimeEnd     string `json:"time_end" binding:"required"`
+	LessonType  string `json:"lesson_type,omitempty"`
+}
+
+func inputOf(s *models.Schedule) scheduleInput {
+	return scheduleInput{
+		ClassCode:   s.ClassCode,
+		ClassName:   s.ClassName,
+		SubjectCode: s.SubjectCode,
+		TeacherNIK:  s.TeacherNIK,
+		TeacherName: s.TeacherName,
+		Date:        s.Date.Format("2006-01-02"),
+		JamKe:       s.JamKe,
+		TimeStart:   s.TimeStart,
+		TimeEnd:     s.TimeEnd,
+		LessonType:  s.LessonType,
+	}
+}
+
+// schedule converts the input into a lesson without identity or
+// timestamps, reading the date in the school's zone loc.
+func (in scheduleInput) schedule(loc *time.Location) (models.Schedule, validate.Errors) {
+	var errs validate.Errors
+	date, err := tz.ParseDate(in.Date, loc)
+	if err != nil {
+		errs.Add("date", validate.CodeFormat, "must be YYYY-MM-DD, got %q", in.Date)
+	}
+	if !models.ValidLessonType(in.LessonType) {
+		errs.Add("lesson_type", validate.CodeUnknown, "must be regular, extra or substitute")
+	}
+	return models.Schedule{
 		ClassCode:   in.ClassCode,
 		ClassName:   in.ClassName,
 		SubjectCode: in.SubjectCode,
@@ -155,13 +402,117 @@ func (h *Handler) Create(c *gin.Context) {
 		JamKe:       in.JamKe,
 		TimeStart:   in.TimeStart,
 		TimeEnd:     in.TimeEnd,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		LessonType:  in.LessonType,
+	}, errs
+}
+
+// rules loads what lessons are validated against.
+func (h *Handler) rules() (validate.Rules, error) {
+	periods, err := h.Bell.Get()
+	if err != nil {
+		return validate.Rules{}, err
 	}
-	if err := h.Repo.Insert(s); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	reqs, err := h.Curriculum.Find("")
+	if err != nil {
+		return validate.Rules{}, err
+	}
+	return validate.NewRules(h.Cfg.NIKPattern, periods, reqs), nil
+}
+
+// bindSchedule binds and validates a full lesson. Field problems are
+// answered with 422 and the list of fields.
+func (h *Handler) bindSchedule(c *gin.Context) (scheduleInput, bool) {
+	var in scheduleInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return in, false
+		}
+		badRequest(c, err.Error())
+		return in, false
+	}
+	return in, true
+}
+
+// checkInput converts in into a lesson and validates its fields.
+func (h *Handler) checkInput(c *gin.Context, in scheduleInput) (models.Schedule, bool) {
+	s, err := h.validInput(in)
+	if err != nil {
+		fail(c, err)
+		return s, false
+	}
+	return s, true
+}
+
+// validInput converts in into a lesson. Field problems are returned as
+// validate.Errors.
+func (h *Handler) validInput(in scheduleInput) (models.Schedule, error) {
+	s, errs := in.schedule(h.Zone)
+	rules, err := h.rules()
+	if err != nil {
+		return s, err
+	}
+	errs = append(errs, rules.Schedule(s)...)
+	if len(errs) > 0 {
+		return s, errs
+	}
+	return s, nil
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
+	in, ok := h.bindSchedule(c)
+	if !ok {
+		return
+	}
+	s, ok := h.checkInput(c, in)
+	if !ok {
+		return
+	}
+
+	reason, err := h.CheckSlot(s.Date, s.ClassCode, s.TeacherNIK, s.JamKe, s.TimeStart, s.TimeEnd, "")
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if reason != "" {
+		scheduleConflict(c, reason)
+		return
+	}
+	if err := h.writable(s.Date); err != nil {
+		fail(c, err)
+		return
+	}
+	now := time.Now()
+	s.UUID = uuid.New().String()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	if err := h.stamp(&s); err != nil {
+		fail(c, err)
+		return
+	}
+	warnings, err := h.CheckWorkload(s)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if len(warnings) > 0 && h.blocksWorkload() {
+		workloadExceeded(c, strings.Join(warnings, "; "))
 		return
 	}
+	s.Warnings = warnings
+	if err := h.Repo.Insert(&s); err != nil {
+		fail(c, err)
+		return
+	}
+	h.emit(events.Created, &s, nil)
 	c.JSON(http.StatusCreated, s)
 }
 
@@ -169,9 +520,14 @@ func (h *Handler) GetAll(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
 	rows, err := h.Repo.FindAll()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, rows)
@@ -181,140 +537,160 @@ func (h *Handler) GetByUUID(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
 	uuidStr := c.Param("uuid")
 	if uuidStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uuid required"})
+		badRequest(c, "uuid required")
 		return
 	}
 	row, err := h.Repo.FindByUUID(uuidStr)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, row)
 }
 
+// Update replaces a lesson as a whole. The body is validated like Create;
+// use Patch to change single fields.
 func (h *Handler) Update(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
 	uuidParam := c.Param("uuid")
 	if uuidParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uuid required"})
+		badRequest(c, "uuid required")
 		return
 	}
-	var in struct {
-		ClassCode   string `json:"class_code"`
-		ClassName   string `json:"class_name"`
-		SubjectCode string `json:"subject_code"`
-		TeacherNIK  string `json:"teacher_nik"`
-		TeacherName string `json:"teacher_name"`
-		Date        string `json:"date"`
-		JamKe       *int   `json:"jam_ke"`
-		TimeStart   string `json:"time_start"`
-		TimeEnd     string `json:"time_end"`
-	}
-	if err := c.ShouldBindJSON(&in); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	in, ok := h.bindSchedule(c)
+	if !ok {
 		return
 	}
 	existing, err := h.Repo.FindByUUID(uuidParam)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
+	h.replace(c, existing, in)
+}
 
-	date := existing.Date
-	if in.Date != "" {
-		if d, err := parseDate(in.Date); err == nil {
-			date = d
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format"})
-			return
-		}
-	}
-	classCode := existing.ClassCode
-	if in.ClassCode != "" {
-		classCode = in.ClassCode
-	}
-	teacherNIK := existing.TeacherNIK
-	if in.TeacherNIK != "" {
-		teacherNIK = in.TeacherNIK
-	}
-	timeStart := existing.TimeStart
-	if in.TimeStart != "" {
-		timeStart = in.TimeStart
+// replace validates in as the new state of existing and saves it.
+func (h *Handler) replace(c *gin.Context, existing *models.Schedule, in scheduleInput) {
+	s, warnings, err := h.update(existing, in)
+	if err != nil {
+		fail(c, err)
+		return
 	}
-	timeEnd := existing.TimeEnd
-	if in.TimeEnd != "" {
-		timeEnd = in.TimeEnd
+	resp := gin.H{"message": "updated", "schedule": s}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
 	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	conflict, err := h.DetectConflict(date, classCode, teacherNIK, timeStart, timeEnd, uuidParam)
+// update is the Update path shared by PUT, PATCH and approved change
+// requests: it validates in as the new state of existing, checks it like
+// a new lesson and saves it. It returns the saved lesson and any workload
+// warnings.
+func (h *Handler) update(existing *models.Schedule, in scheduleInput) (models.Schedule, []string, error) {
+	s, warnings, err := h.checkUpdate(existing, in)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return s, nil, err
 	}
-	if conflict {
-		c.JSON(http.StatusConflict, gin.H{"error": "schedule conflict detected"})
-		return
+	s.UpdatedAt = time.Now()
+	if err := h.stamp(&s); err != nil {
+		return s, nil, err
 	}
 	update := bson.M{
-		"updated_at":  time.Now(),
-		"date":        date,
-		"class_code":  classCode,
-		"time_start":  timeStart,
-		"time_end":    timeEnd,
-		"teacher_nik": teacherNIK,
+		"updated_at":   s.UpdatedAt,
+		"class_code":   s.ClassCode,
+		"class_name":   s.ClassName,
+		"subject_code": s.SubjectCode,
+		"teacher_nik":  s.TeacherNIK,
+		"teacher_name": s.TeacherName,
+		"date":         s.Date,
+		"jam_ke":       s.JamKe,
+		"time_start":   s.TimeStart,
+		"time_end":     s.TimeEnd,
+		"lesson_type":  s.LessonType,
+		"start_at":     s.StartAt,
+		"end_at":       s.EndAt,
+		"term_id":      s.TermID,
+	}
+	if err := h.Repo.UpdateByUUID(s.UUID, update); err != nil {
+		return s, nil, err
+	}
+	h.emit(events.Updated, &s, existing)
+	return s, warnings, nil
+}
+
+// checkUpdate runs the checks of update without saving anything.
+func (h *Handler) checkUpdate(existing *models.Schedule, in scheduleInput) (models.Schedule, []string, error) {
+	s, err := h.validInput(in)
+	if err != nil {
+		return s, nil, err
 	}
-	if in.ClassName != "" {
-		update["class_name"] = in.ClassName
+	s.ID = existing.ID
+	s.UUID = existing.UUID
+	s.CreatedAt = existing.CreatedAt
+	if err := h.writable(existing.Date, s.Date); err != nil {
+		return s, nil, err
 	}
-	if in.SubjectCode != "" {
-		update["subject_code"] = in.SubjectCode
+
+	reason, err := h.CheckSlot(s.Date, s.ClassCode, s.TeacherNIK, s.JamKe, s.TimeStart, s.TimeEnd, s.UUID)
+	if err != nil {
+		return s, nil, err
 	}
-	if in.TeacherName != "" {
-		update["teacher_name"] = in.TeacherName
+	if reason != "" {
+		return s, nil, slotConflictError(reason)
 	}
-	if in.JamKe != nil {
-		update["jam_ke"] = *in.JamKe
+	warnings, err := h.CheckWorkload(s)
+	if err != nil {
+		return s, nil, err
 	}
-	if err := h.Repo.UpdateByUUID(uuidParam, update); err != nil {
-		if err.Error() == "not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if len(warnings) > 0 && h.blocksWorkload() {
+		return s, nil, workloadError(strings.Join(warnings, "; "))
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "updated"})
+	return s, warnings, nil
 }
 
 func (h *Handler) Delete(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
 	uuidStr := c.Param("uuid")
 	if uuidStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uuid required"})
+		badRequest(c, "uuid required")
+		return
+	}
+	existing, err := h.Repo.FindByUUID(uuidStr)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.writable(existing.Date); err != nil {
+		fail(c, err)
 		return
 	}
 	if err := h.Repo.DeleteByUUID(uuidStr); err != nil {
-		if err.Error() == "not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
+	h.emit(events.Deleted, existing, nil)
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
@@ -322,25 +698,36 @@ func (h *Handler) StudentSchedule(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
 	classCode := c.Query("class_code")
 	dateStr := c.Query("date")
-	if classCode == "" || dateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "class_code and date required"})
+	term, ok := h.termParam(c)
+	if !ok {
 		return
 	}
-	date, err := parseDate(dateStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+	if classCode == "" || (dateStr == "" && term == nil) {
+		badRequest(c, "class_code and date or term required")
 		return
 	}
-	cur, err := h.Coll.Find(h.Ctx, bson.M{"class_code": classCode, "date": date})
+	filter := bson.M{"class_code": classCode}
+	if term != nil {
+		filter["term_id"] = term.ID
+	} else {
+		date, err := h.parseDate(dateStr)
+		if err != nil {
+			badRequest(c, "invalid date")
+			return
+		}
+		filter["date"] = date
+	}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(filter))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 	var out []models.Schedule
 	if err := cur.All(h.Ctx, &out); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, out)
@@ -350,127 +737,133 @@ func (h *Handler) TeacherSchedule(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
 	nik := c.Query("teacher_nik")
-	sd := c.Query("start_date")
-	ed := c.Query("end_date")
-	if nik == "" || sd == "" || ed == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "teacher_nik, start_date, end_date required"})
+	if nik == "" {
+		badRequest(c, "teacher_nik required")
 		return
 	}
-	start, err := parseDate(sd)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date"})
-		return
-	}
-	end, err := parseDate(ed)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date"})
+	_, _, filter, ok := h.periodFilter(c)
+	if !ok {
 		return
 	}
-	filter := bson.M{
-		"teacher_nik": nik,
-		"date":        bson.M{"$gte": start, "$lte": end},
-	}
-	cur, err := h.Coll.Find(h.Ctx, filter)
+	rows, j, err := h.journalRows(filter, nik)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
-	var rows []models.Schedule
-	if err := cur.All(h.Ctx, &rows); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	own := []models.Schedule{}
+	for _, s := range rows {
+		if s.TeacherNIK == nik {
+			own = append(own, s)
+		}
 	}
-	totalJP := len(rows)
-	c.JSON(http.StatusOK, gin.H{"schedules": rows, "total_jp": totalJP})
+	c.JSON(http.StatusOK, gin.H{
+		"schedules":     own,
+		"total_lessons": len(own),
+		"total_jp":      h.JP.Total(own),
+		"journal":       journal.Of(journal.ByTeacher(rows, h.JP, j), nik),
+	})
 }
 
 func (h *Handler) ExportJP(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
 
-	sd := c.Query("start_date")
-	ed := c.Query("end_date")
-	if sd == "" || ed == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date required"})
-		return
-	}
-	start, err := parseDate(sd)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date"})
+	start, end, filter, ok := h.periodFilter(c)
+	if !ok {
 		return
 	}
-	end, err := parseDate(ed)
+	rows, j, err := h.journalRows(filter, "")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date"})
+		fail(c, err)
 		return
 	}
 
-	filter := bson.M{"date": bson.M{"$gte": start, "$lte": end}}
-	cur, err := h.Coll.Find(h.Ctx, filter)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	var rows []models.Schedule
-	if err := cur.All(h.Ctx, &rows); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	weeks := calendar.Weeks(start, end)
 
 	type teacherAgg struct {
-		NIK     string
-		Name    string
-		Classes map[string]struct{}
-		Weeks   [5]int
-		TotalJP int
+		NIK          string
+		Name         string
+		Classes      map[string]struct{}
+		Weeks        []float64
+		TotalLessons int
+		TotalJP      float64
+		DeliveredJP  float64
+		CancelledJP  float64
 	}
 	data := map[string]*teacherAgg{}
-
-	for _, r := range rows {
-		if _, ok := data[r.TeacherNIK]; !ok {
-			data[r.TeacherNIK] = &teacherAgg{
-				NIK:     r.TeacherNIK,
-				Name:    r.TeacherName,
+	get := func(nik, name string) *teacherAgg {
+		if _, ok := data[nik]; !ok {
+			data[nik] = &teacherAgg{
+				NIK:     nik,
+				Name:    name,
 				Classes: map[string]struct{}{},
+				Weeks:   make([]float64, len(weeks)),
 			}
 		}
-		ag := data[r.TeacherNIK]
+		return data[nik]
+	}
+
+	for _, r := range rows {
+		ag := get(r.TeacherNIK, r.TeacherName)
 
 		ag.Classes[r.ClassCode] = struct{}{}
 
-		day := r.Date.Day()
-		week := (day-1)/7 + 1
-		if week < 1 {
-			week = 1
-		}
-		if week > 5 {
-			week = 5
+		lessonJP := h.JP.Of(r)
+		if week := calendar.WeekIndex(start, r.Date); week >= 0 && week < len(weeks) {
+			ag.Weeks[week] += lessonJP
 		}
-		ag.Weeks[week-1]++
-		ag.TotalJP++
+		ag.TotalLessons++
+		ag.TotalJP += lessonJP
+	}
+	// Delivered JP follow the journal, so a substitute is credited with
+	// the lessons taken even without lessons of their own.
+	for _, t := range journal.ByTeacher(rows, h.JP, j) {
+		ag := get(t.TeacherNIK, t.TeacherName)
+		ag.DeliveredJP = t.DeliveredJP
+		ag.CancelledJP = t.CancelledJP
 	}
 
 	f := excelize.NewFile()
 	sheet := "RekapJP"
 	f.SetSheetName("Sheet1", sheet)
 
+	// Columns: A-D teacher info, one column per school week of the
+	// requested range, then lesson count, planned JP and the JP the
+	// journal records as delivered and cancelled.
+	col := func(n int) string {
+		name, _ := excelize.ColumnNumberToName(n)
+		return name
+	}
+	firstWeekCol := 5
+	lastWeekCol := firstWeekCol + len(weeks) - 1
+	lessonsCol := col(lastWeekCol + 1)
+	totalCol := col(lastWeekCol + 2)
+	deliveredCol := col(lastWeekCol + 3)
+	cancelledCol := col(lastWeekCol + 4)
+
 	f.SetCellValue(sheet, "A1", "No")
 	f.SetCellValue(sheet, "B1", "NIK")
 	f.SetCellValue(sheet, "C1", "Nama Pengajar")
 	f.SetCellValue(sheet, "D1", "Kelas yg Diajar")
 
-	f.MergeCell(sheet, "E1", "I1")
-	f.SetCellValue(sheet, "E1", "Total Jam Pelajaran Per Pekan")
+	if len(weeks) > 1 {
+		f.MergeCell(sheet, col(firstWeekCol)+"1", col(lastWeekCol)+"1")
+	}
+	f.SetCellValue(sheet, col(firstWeekCol)+"1", "Total Jam Pelajaran Per Pekan")
 
-	f.SetCellValue(sheet, "J1", "Total JP")
+	f.SetCellValue(sheet, lessonsCol+"1", "Jumlah Pertemuan")
+	f.SetCellValue(sheet, totalCol+"1", "Total JP Terjadwal")
+	f.SetCellValue(sheet, deliveredCol+"1", "JP Terlaksana")
+	f.SetCellValue(sheet, cancelledCol+"1", "JP Batal")
 
-	f.SetCellValue(sheet, "E2", "Pekan 1")
-	f.SetCellValue(sheet, "F2", "Pekan 2")
-	f.SetCellValue(sheet, "G2", "Pekan 3")
-	f.SetCellValue(sheet, "H2", "Pekan 4")
-	f.SetCellValue(sheet, "I2", "Pekan 5")
+	for i, w := range weeks {
+		f.SetCellValue(sheet, fmt.Sprintf("%s2", col(firstWeekCol+i)),
+			fmt.Sprintf("Pekan %d\n%s - %s", i+1, w.Start.Format("02/01/2006"), w.End.Format("02/01/2006")))
+	}
 
 	headerStyle, _ := f.NewStyle(&excelize.Style{
 		Font:      &excelize.Font{Bold: true},
@@ -485,9 +878,10 @@ func (h *Handler) ExportJP(c *gin.Context) {
 	})
 
 	f.SetCellStyle(sheet, "A1", "D1", headerStyle)
-	f.SetCellStyle(sheet, "E1", "E1", headerStyle) 
-	f.SetCellStyle(sheet, "J1", "J1", headerStyle)
-	f.SetCellStyle(sheet, "A2", "J2", headerStyle)
+	f.SetCellStyle(sheet, col(firstWeekCol)+"1", col(firstWeekCol)+"1", headerStyle)
+	f.SetCellStyle(sheet, lessonsCol+"1", cancelledCol+"1", headerStyle)
+	f.SetCellStyle(sheet, "A2", cancelledCol+"2", headerStyle)
+	f.SetRowHeight(sheet, 2, 32)
 
 	keys := make([]string, 0, len(data))
 	for k := range data {
@@ -511,24 +905,22 @@ func (h *Handler) ExportJP(c *gin.Context) {
 		f.SetCellValue(sheet, fmt.Sprintf("B%d", rowIndex), ag.NIK)
 		f.SetCellValue(sheet, fmt.Sprintf("C%d", rowIndex), ag.Name)
 		f.SetCellValue(sheet, fmt.Sprintf("D%d", rowIndex), classesStr)
-		f.SetCellValue(sheet, fmt.Sprintf("E%d", rowIndex), ag.Weeks[0])
-		f.SetCellValue(sheet, fmt.Sprintf("F%d", rowIndex), ag.Weeks[1])
-		f.SetCellValue(sheet, fmt.Sprintf("G%d", rowIndex), ag.Weeks[2])
-		f.SetCellValue(sheet, fmt.Sprintf("H%d", rowIndex), ag.Weeks[3])
-		f.SetCellValue(sheet, fmt.Sprintf("I%d", rowIndex), ag.Weeks[4])
-		f.SetCellValue(sheet, fmt.Sprintf("J%d", rowIndex), ag.TotalJP)
+		for i, v := range ag.Weeks {
+			f.SetCellValue(sheet, fmt.Sprintf("%s%d", col(firstWeekCol+i), rowIndex), v)
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("%s%d", lessonsCol, rowIndex), ag.TotalLessons)
+		f.SetCellValue(sheet, fmt.Sprintf("%s%d", totalCol, rowIndex), ag.TotalJP)
+		f.SetCellValue(sheet, fmt.Sprintf("%s%d", deliveredCol, rowIndex), ag.DeliveredJP)
+		f.SetCellValue(sheet, fmt.Sprintf("%s%d", cancelledCol, rowIndex), ag.CancelledJP)
 
 		rowIndex++
 	}
 
-	for col := 1; col <= 10; col++ {
-		colName, _ := excelize.ColumnNumberToName(col)
-		f.SetColWidth(sheet, colName, colName, 18)
-	}
+	f.SetColWidth(sheet, "A", cancelledCol, 18)
 
 	var buf bytes.Buffer
 	if err := f.Write(&buf); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
@@ -540,31 +932,43 @@ func (h *Handler) ImportExcel(c *gin.Context) {
 	if !authorize(c) {
 		return
 	}
+	h = h.tenant(c)
+	h, ok := h.inDraft(c)
+	if !ok {
+		return
+	}
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		badRequest(c, "file is required")
 		return
 	}
 	f, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 	defer f.Close()
 
 	xl, err := excelize.OpenReader(f)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid excel file"})
+		badRequest(c, "invalid excel file")
 		return
 	}
 	rows, err := xl.GetRows("Sheet1")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "sheet Sheet1 not found"})
+		badRequest(c, "sheet Sheet1 not found")
+		return
+	}
+
+	rules, err := h.rules()
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
 	inserted := 0
 	failures := []string{}
+	warnings := []string{}
 
 	for i, row := range rows {
 		if i == 0 {
@@ -576,7 +980,7 @@ func (h *Handler) ImportExcel(c *gin.Context) {
 			continue
 		}
 
-		date, err := parseDate(strings.TrimSpace(row[5]))
+		date, err := h.parseDate(strings.TrimSpace(row[5]))
 		if err != nil {
 			failures = append(failures, fmt.Sprintf("row %d: invalid date %s", i+1, row[5]))
 			continue
@@ -593,14 +997,12 @@ func (h *Handler) ImportExcel(c *gin.Context) {
 		teacherName := strings.TrimSpace(row[4])
 		timeStart := strings.TrimSpace(row[7])
 		timeEnd := strings.TrimSpace(row[8])
-
-		conflict, err := h.DetectConflict(date, classCode, teacherNIK, timeStart, timeEnd, "")
-		if err != nil {
-			failures = append(failures, fmt.Sprintf("row %d: error checking conflict %v", i+1, err))
-			continue
+		lessonType := ""
+		if len(row) > 9 {
+			lessonType = strings.ToLower(strings.TrimSpace(row[9]))
 		}
-		if conflict {
-			failures = append(failures, fmt.Sprintf("row %d: conflict detected", i+1))
+		if !models.ValidLessonType(lessonType) {
+			failures = append(failures, fmt.Sprintf("row %d: invalid lesson_type %s", i+1, row[9]))
 			continue
 		}
 
@@ -616,23 +1018,67 @@ func (h *Handler) ImportExcel(c *gin.Context) {
 			JamKe:       jk,
 			TimeStart:   timeStart,
 			TimeEnd:     timeEnd,
+			LessonType:  lessonType,
 			CreatedAt:   now,
 			UpdatedAt:   now,
 		}
+		if err := h.stamp(s); err != nil {
+			failures = append(failures, fmt.Sprintf("row %d: %v", i+1, err))
+			continue
+		}
+		if errs := rules.Schedule(*s); len(errs) > 0 {
+			failures = append(failures, fmt.Sprintf("row %d: invalid: %s", i+1, errs.Error()))
+			continue
+		}
+		if err := h.writable(date); err != nil {
+			failures = append(failures, fmt.Sprintf("row %d: %v", i+1, err))
+			continue
+		}
+
+		reason, err := h.CheckSlot(date, classCode, teacherNIK, jk, timeStart, timeEnd, "")
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("row %d: error checking conflict %v", i+1, err))
+			continue
+		}
+		if reason != "" {
+			failures = append(failures, fmt.Sprintf("row %d: conflict detected: %s", i+1, reason))
+			continue
+		}
+		exceeded, err := h.CheckWorkload(*s)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("row %d: error checking workload %v", i+1, err))
+			continue
+		}
+		if len(exceeded) > 0 && h.blocksWorkload() {
+			failures = append(failures, fmt.Sprintf("row %d: workload limit exceeded: %s", i+1, strings.Join(exceeded, "; ")))
+			continue
+		}
 		if err := h.Repo.Insert(s); err != nil {
 			failures = append(failures, fmt.Sprintf("row %d: insert error %v", i+1, err))
 			continue
 		}
+		h.emit(events.Created, s, nil)
+		for _, w := range exceeded {
+			warnings = append(warnings, fmt.Sprintf("row %d: %s", i+1, w))
+		}
 		inserted++
 	}
 
 	if len(failures) == 0 {
-		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Upload sukses, %d baris data ditambahkan.", inserted)})
+		resp := gin.H{"message": fmt.Sprintf("Upload sukses, %d baris data ditambahkan.", inserted)}
+		if len(warnings) > 0 {
+			resp["warnings"] = warnings
+		}
+		c.JSON(http.StatusOK, resp)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"message":  fmt.Sprintf("Upload selesai, %d baris data ditambahkan, %d baris gagal.", inserted, len(failures)),
 		"failures": failures,
-	})
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, resp)
 }
@@ -3,8 +3,11 @@ package handlers_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
@@ -44,6 +48,67 @@ func setupTest() (*gin.Engine, *repo.MongoRepo, *handlers.Handler) {
 	return r, repo, h
 }
 
+// Keys of the schools setupSchools serves.
+const (
+	defaultKey = "SECRET123"
+	adminKey   = "admin-one"
+	otherKey   = "key-two"
+)
+
+// setupSchools is setupTest for tests acting for more than one school: the
+// default school with defaultKey and its admin key adminKey, and school
+// sma2 with otherKey. Routes are left to the test. It skips when MongoDB
+// is not running.
+func setupSchools(t *testing.T) (*gin.Engine, *handlers.Handler) {
+	t.Setenv("API_KEY", defaultKey)
+	t.Setenv("SCHOOL_API_KEYS", "sma2:"+otherKey)
+	t.Setenv("SCHOOL_ADMIN_KEYS", "default:"+adminKey)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err == nil {
+		err = client.Ping(ctx, nil)
+	}
+	if err != nil {
+		t.Skipf("MongoDB not available: %v", err)
+	}
+	coll := client.Database("testdb").Collection("schedules")
+	h := handlers.NewHandler(repo.NewMongoRepo(coll), coll)
+
+	gin.SetMode(gin.TestMode)
+	return gin.New(), h
+}
+
+// call serves one request with apiKey and a JSON body, if any.
+func call(r *gin.Engine, method, path, apiKey, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", apiKey)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+// newNIK returns a fresh NIK matching validate.DefaultNIKPattern.
+func newNIK() string {
+	return strconv.FormatInt(rand.Int63(), 10)
+}
+
+// createLesson creates a lesson of teacher nik, in a class of its own,
+// through the POST /schedules route of r and returns its UUID.
+func createLesson(t *testing.T, r *gin.Engine, nik, date, start, end string) string {
+	t.Helper()
+	body := fmt.Sprintf(`{"class_code": %q, "class_name": "X", "subject_code": "MTK", "teacher_nik": %q,
+		"teacher_name": "Budi", "date": %q, "jam_ke": 1, "time_start": %q, "time_end": %q}`,
+		uuid.New().String(), nik, date, start, end)
+	resp := call(r, http.MethodPost, "/schedules", defaultKey, body)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	var s models.Schedule
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &s))
+	return s.UUID
+}
+
 func TestCreateSuccess(t *testing.T) {
 	r, _, _ := setupTest()
 
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func (h *Handler) ListTeachers(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Teachers.FindAll()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+func (h *Handler) GetTeacher(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	t, err := h.Teachers.FindByNIK(c.Param("nik"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// PutTeacher creates or replaces the profile of the teacher in the path.
+func (h *Handler) PutTeacher(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		Name           string  `json:"name" binding:"required"`
+		Email          string  `json:"email" binding:"omitempty,email"`
+		EmploymentType string  `json:"employment_type"`
+		MinWeeklyJP    float64 `json:"min_weekly_jp"`
+		MaxWeeklyJP    float64 `json:"max_weekly_jp"`
+		MaxDailyJP     float64 `json:"max_daily_jp"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if in.MinWeeklyJP < 0 || in.MaxWeeklyJP < 0 || in.MaxDailyJP < 0 ||
+		(in.MaxWeeklyJP > 0 && in.MinWeeklyJP > in.MaxWeeklyJP) {
+		badRequest(c, "invalid workload limits")
+		return
+	}
+	t := &models.Teacher{
+		NIK:            c.Param("nik"),
+		Name:           in.Name,
+		Email:          in.Email,
+		EmploymentType: in.EmploymentType,
+		MinWeeklyJP:    in.MinWeeklyJP,
+		MaxWeeklyJP:    in.MaxWeeklyJP,
+		MaxDailyJP:     in.MaxDailyJP,
+	}
+	if err := h.Teachers.Upsert(t); err != nil {
+		fail(c, err)
+		return
+	}
+	saved, err := h.Teachers.FindByNIK(t.NIK)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/config"
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// withSchool overlays the settings a school configured on the
+// deployment-wide defaults.
+func withSchool(cfg config.Config, s models.School) config.Config {
+	if s.Name != "" {
+		cfg.SchoolName = s.Name
+	}
+	if s.Address != "" {
+		cfg.SchoolAddress = s.Address
+	}
+	if s.Timezone != "" {
+		cfg.SchoolTimezone = s.Timezone
+	}
+	if s.JPMinutes > 0 {
+		cfg.JPMinutes = s.JPMinutes
+	}
+	if s.JPRounding != "" {
+		cfg.JPRounding = s.JPRounding
+	}
+	if s.WorkloadMinWeeklyJP > 0 {
+		cfg.WorkloadMinWeeklyJP = s.WorkloadMinWeeklyJP
+	}
+	if s.WorkloadMaxWeeklyJP > 0 {
+		cfg.WorkloadMaxWeeklyJP = s.WorkloadMaxWeeklyJP
+	}
+	if s.WorkloadMaxDailyJP > 0 {
+		cfg.WorkloadMaxDailyJP = s.WorkloadMaxDailyJP
+	}
+	if s.WorkloadMode != "" {
+		cfg.WorkloadMode = s.WorkloadMode
+	}
+	if s.NIKPattern != "" {
+		cfg.NIKPattern = s.NIKPattern
+	}
+	return cfg
+}
+
+// checkSchool validates the settings a school may override.
+func checkSchool(s models.School) validate.Errors {
+	var errs validate.Errors
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			errs.Add("timezone", validate.CodeFormat, "unknown time zone %q", s.Timezone)
+		}
+	}
+	if s.JPMinutes < 0 {
+		errs.Add("jp_minutes", validate.CodeRange, "must not be negative")
+	}
+	if !jp.ValidRounding(s.JPRounding) {
+		errs.Add("jp_rounding", validate.CodeFormat, "unknown rounding %q", s.JPRounding)
+	}
+	if s.WorkloadMinWeeklyJP < 0 {
+		errs.Add("workload_min_weekly_jp", validate.CodeRange, "must not be negative")
+	}
+	if s.WorkloadMaxWeeklyJP < 0 {
+		errs.Add("workload_max_weekly_jp", validate.CodeRange, "must not be negative")
+	}
+	if s.WorkloadMaxDailyJP < 0 {
+		errs.Add("workload_max_daily_jp", validate.CodeRange, "must not be negative")
+	}
+	if s.WorkloadMode != "" && s.WorkloadMode != "warn" && s.WorkloadMode != "block" {
+		errs.Add("workload_mode", validate.CodeFormat, "must be warn or block")
+	}
+	if s.NIKPattern != "" {
+		if _, err := regexp.Compile(s.NIKPattern); err != nil {
+			errs.Add("nik_pattern", validate.CodeFormat, "invalid regular expression")
+		}
+	}
+	return errs
+}
+
+// forSchool returns a copy of h whose repositories, configuration, JP
+// calculator and zone all belong to school.
+func (h *Handler) forSchool(school string) *Handler {
+	t := *h
+	t.School = school
+	t.Repo = h.Repo.For(school)
+	t.Teachers = h.Teachers.For(school)
+	t.Rates = h.Rates.For(school)
+	t.Availability = h.Availability.For(school)
+	t.Curriculum = h.Curriculum.For(school)
+	t.Bell = h.Bell.For(school)
+	t.Holidays = h.Holidays.For(school)
+	t.Audit = h.Audit.For(school)
+	t.Terms = h.Terms.For(school)
+	t.Drafts = h.Drafts.For(school)
+	t.Changes = h.Changes.For(school)
+	t.Webhooks = h.Webhooks.For(school)
+	t.Digests = h.Digests.For(school)
+	t.Attendance = h.Attendance.For(school)
+	t.Journal = h.Journal.For(school)
+	t.terms, t.termsLoaded = nil, false
+	t.draft, t.openDrafts, t.draftsLoaded = nil, nil, false
+
+	settings, err := h.Schools.Get(school)
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) {
+			log.Printf("load settings of school %s: %v", school, err)
+		}
+		return &t
+	}
+	t.Cfg = withSchool(h.Cfg, *settings)
+	t.JP = jp.Calculator{LessonMinutes: t.Cfg.JPMinutes, Rounding: t.Cfg.JPRounding}
+	if settings.Timezone != "" {
+		if zone, err := time.LoadLocation(settings.Timezone); err == nil {
+			t.Zone = zone
+		}
+	}
+	return &t
+}
+
+// AdoptOrphans assigns data stored before multi-school support to the
+// default school.
+func (h *Handler) AdoptOrphans() error {
+	return repo.AdoptOrphans(h.Ctx,
+		h.Coll, h.Teachers.Coll, h.Rates.Coll,
+		h.Availability.Windows, h.Availability.Leaves,
+		h.Curriculum.Coll, h.Bell.Coll, h.Holidays.Coll, h.Audit.Coll)
+}
+
+// tenant scopes h to the school of the request's API key.
+func (h *Handler) tenant(c *gin.Context) *Handler {
+	return h.forSchool(tenant.From(c))
+}
+
+// GetSchool returns the settings of the caller's school.
+func (h *Handler) GetSchool(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+
+	s, err := h.Schools.Get(h.School)
+	if errors.Is(err, repo.ErrNotFound) {
+		s, err = &models.School{ID: h.School}, nil
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// PutSchool replaces the settings of the caller's school. Empty fields
+// fall back to the deployment-wide defaults.
+func (h *Handler) PutSchool(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+
+	var s models.School
+	if err := c.ShouldBindJSON(&s); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	s.ID = h.School
+	if errs := checkSchool(s); len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	if err := h.Schools.Upsert(&s); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
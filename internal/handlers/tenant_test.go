@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/config"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+)
+
+func TestWithSchoolOverridesOnlySetFields(t *testing.T) {
+	def := config.Config{SchoolName: "Env", JPMinutes: 45, WorkloadMaxWeeklyJP: 40, WorkloadMode: "warn"}
+
+	got := withSchool(def, models.School{Name: "SMA 2", JPMinutes: 40, WorkloadMode: "block"})
+
+	assert.Equal(t, "SMA 2", got.SchoolName)
+	assert.Equal(t, 40, got.JPMinutes)
+	assert.Equal(t, "block", got.WorkloadMode)
+	assert.Equal(t, 40.0, got.WorkloadMaxWeeklyJP)
+	assert.Equal(t, def, withSchool(def, models.School{}))
+}
+
+func TestCheckSchool(t *testing.T) {
+	assert.Empty(t, checkSchool(models.School{Timezone: "Asia/Jayapura", JPRounding: "up", WorkloadMode: "warn"}))
+
+	errs := checkSchool(models.School{
+		Timezone:     "Mars/Olympus",
+		JPMinutes:    -1,
+		JPRounding:   "sideways",
+		WorkloadMode: "ignore",
+		NIKPattern:   "([",
+	})
+	fields := []string{}
+	for _, fe := range errs {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"timezone", "jp_minutes", "jp_rounding", "workload_mode", "nik_pattern"}, fields)
+}
+
+func TestAuthorizeResolvesSchool(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SCHOOL_API_KEYS", "sma2:key-two")
+	t.Setenv("API_KEY", "")
+
+	for key, want := range map[string]string{"key-two": "sma2", "SECRET123": tenant.Default} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("x-api-key", key)
+		assert.True(t, authorize(c), key)
+		assert.Equal(t, want, tenant.From(c))
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("x-api-key", "other")
+	assert.False(t, authorize(c))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/repo"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// termLockedError rejects a change to lessons of a locked term.
+type termLockedError struct {
+	Term models.Term
+}
+
+func (e termLockedError) Error() string {
+	return fmt.Sprintf("term %s is locked", e.Term.Name)
+}
+
+// loadTerms returns the school's terms, read once per request.
+func (h *Handler) loadTerms() ([]models.Term, error) {
+	if h.termsLoaded {
+		return h.terms, nil
+	}
+	terms, err := h.Terms.Find(primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+	h.terms, h.termsLoaded = terms, true
+	return terms, nil
+}
+
+// unlocked fails with a termLockedError when one of dates lies in a locked
+// term.
+func (h *Handler) unlocked(dates ...time.Time) error {
+	terms, err := h.loadTerms()
+	if err != nil {
+		return err
+	}
+	if t, ok := calendar.LockedTerm(terms, dates...); ok {
+		return termLockedError{Term: t}
+	}
+	return nil
+}
+
+// termOf returns the ID of the term containing d, or nil when no term
+// does. It fails when the terms cannot be read, so that no lesson is saved
+// unlinked from its term.
+func (h *Handler) termOf(d time.Time) (*primitive.ObjectID, error) {
+	terms, err := h.loadTerms()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := calendar.TermOn(terms, d); ok {
+		return &t.ID, nil
+	}
+	return nil, nil
+}
+
+// termParam resolves the optional ?term= parameter. It reports false after
+// answering a bad or unknown term.
+func (h *Handler) termParam(c *gin.Context) (*models.Term, bool) {
+	raw := c.Query("term")
+	if raw == "" {
+		return nil, true
+	}
+	id, err := primitive.ObjectIDFromHex(raw)
+	if err != nil {
+		badRequest(c, "invalid term")
+		return nil, false
+	}
+	t, err := h.Terms.Get(id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			notFound(c, fmt.Sprintf("term %s not found", raw))
+			return nil, false
+		}
+		fail(c, err)
+		return nil, false
+	}
+	return t, true
+}
+
+// dateRange reads the start_date and end_date of a request.
+func (h *Handler) dateRange(startStr, endStr string) (time.Time, time.Time, error) {
+	start, err := h.parseDate(startStr)
+	if err != nil {
+		return start, start, errors.New("invalid start_date")
+	}
+	end, err := h.parseDate(endStr)
+	if err != nil {
+		return start, end, errors.New("invalid end_date")
+	}
+	if end.Before(start) {
+		return start, end, errors.New("end_date must not be before start_date")
+	}
+	return start, end, nil
+}
+
+// dateFields reads the start_date and end_date of a request body,
+// reporting bad values as field errors.
+func (h *Handler) dateFields(startStr, endStr string) (time.Time, time.Time, validate.Errors) {
+	var errs validate.Errors
+	start, err := h.parseDate(startStr)
+	if err != nil {
+		errs.Add("start_date", validate.CodeFormat, "must be YYYY-MM-DD")
+	}
+	end, err := h.parseDate(endStr)
+	if err != nil {
+		errs.Add("end_date", validate.CodeFormat, "must be YYYY-MM-DD")
+	}
+	if len(errs) == 0 && end.Before(start) {
+		errs.Add("end_date", validate.CodeOrder, "must not be before start_date")
+	}
+	return start, end, errs
+}
+
+// periodFilter reads either ?term= or ?start_date= and ?end_date= and
+// returns the period with a filter matching its lessons. It reports false
+// after answering a bad request.
+func (h *Handler) periodFilter(c *gin.Context) (time.Time, time.Time, bson.M, bool) {
+	term, ok := h.termParam(c)
+	if !ok {
+		return time.Time{}, time.Time{}, nil, false
+	}
+	if term != nil {
+		return term.StartDate, term.EndDate, bson.M{"term_id": term.ID}, true
+	}
+	sd, ed := c.Query("start_date"), c.Query("end_date")
+	if sd == "" || ed == "" {
+		badRequest(c, "term or start_date and end_date required")
+		return time.Time{}, time.Time{}, nil, false
+	}
+	start, end, err := h.dateRange(sd, ed)
+	if err != nil {
+		badRequest(c, err.Error())
+		return time.Time{}, time.Time{}, nil, false
+	}
+	return start, end, bson.M{"date": bson.M{"$gte": start, "$lte": end}}, true
+}
+
+func (h *Handler) ListAcademicYears(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Terms.FindYears()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+func (h *Handler) AddAcademicYear(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		Name      string `json:"name" binding:"required"`
+		StartDate string `json:"start_date" binding:"required"`
+		EndDate   string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	start, end, errs := h.dateFields(in.StartDate, in.EndDate)
+	if len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	y := &models.AcademicYear{Name: in.Name, StartDate: start, EndDate: end}
+	if err := h.Terms.InsertYear(y); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, y)
+}
+
+// ListTerms lists terms, optionally of ?academic_year_id=.
+func (h *Handler) ListTerms(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var yearID primitive.ObjectID
+	if raw := c.Query("academic_year_id"); raw != "" {
+		var err error
+		if yearID, err = primitive.ObjectIDFromHex(raw); err != nil {
+			badRequest(c, "invalid academic_year_id")
+			return
+		}
+	}
+	rows, err := h.Terms.Find(yearID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+func (h *Handler) GetTerm(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	t, err := h.Terms.Get(id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// AddTerm creates a term within its academic year. Terms of a school must
+// not overlap; lessons already dated within the new term are linked to it.
+func (h *Handler) AddTerm(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		AcademicYearID string `json:"academic_year_id" binding:"required"`
+		Name           string `json:"name" binding:"required"`
+		Semester       int    `json:"semester" binding:"required,min=1"`
+		StartDate      string `json:"start_date" binding:"required"`
+		EndDate        string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		if errs, ok := bindingErrors(&in, err); ok {
+			invalid(c, errs)
+			return
+		}
+		badRequest(c, err.Error())
+		return
+	}
+	start, end, errs := h.dateFields(in.StartDate, in.EndDate)
+	yearID, err := primitive.ObjectIDFromHex(in.AcademicYearID)
+	if err != nil {
+		errs.Add("academic_year_id", validate.CodeFormat, "must be the ID of an academic year")
+	}
+	if len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	year, err := h.Terms.GetYear(yearID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if start.Before(year.StartDate) {
+		errs.Add("start_date", validate.CodeRange, "must not be before the start of academic year %s (%s)",
+			year.Name, year.StartDate.Format("2006-01-02"))
+	}
+	if end.After(year.EndDate) {
+		errs.Add("end_date", validate.CodeRange, "must not be after the end of academic year %s (%s)",
+			year.Name, year.EndDate.Format("2006-01-02"))
+	}
+	if len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	terms, err := h.loadTerms()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if other, ok := calendar.OverlappingTerm(terms, start, end); ok {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict,
+			fmt.Sprintf("term overlaps %s", other.Name), gin.H{"term_id": other.ID})
+		return
+	}
+
+	t := &models.Term{AcademicYearID: yearID, Name: in.Name, Semester: in.Semester, StartDate: start, EndDate: end}
+	if err := h.Terms.Insert(t); err != nil {
+		fail(c, err)
+		return
+	}
+	linked, err := h.Repo.LinkTerm(start, end, t.ID)
+	if err != nil {
+		fail(c, fmt.Errorf("term created but linking lessons failed: %w", err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"term": t, "linked": linked})
+}
+
+// LockTerm archives a finished term: its lessons become read-only.
+func (h *Handler) LockTerm(c *gin.Context) {
+	h.setLocked(c, true)
+}
+
+func (h *Handler) UnlockTerm(c *gin.Context) {
+	h.setLocked(c, false)
+}
+
+func (h *Handler) setLocked(c *gin.Context, locked bool) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	t, err := h.Terms.SetLocked(id, locked)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
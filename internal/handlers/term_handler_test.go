@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateFields(t *testing.T) {
+	h := &Handler{Zone: time.UTC}
+
+	start, end, errs := h.dateFields("2024-07-15", "2024-12-20")
+	assert.Empty(t, errs)
+	assert.Equal(t, time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC), end)
+
+	_, _, errs = h.dateFields("2024-12-20", "2024-07-15")
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "end_date", errs[0].Field)
+	}
+
+	_, _, errs = h.dateFields("15/07/2024", "")
+	assert.Len(t, errs, 2)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/timetable"
+)
+
+func (h *Handler) weekRows(filter bson.M, weekStart time.Time) ([]models.Schedule, error) {
+	filter["date"] = bson.M{"$gte": weekStart, "$lt": weekStart.AddDate(0, 0, 7)}
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(filter))
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (h *Handler) writePDF(c *gin.Context, filename string, grids []timetable.Grid) {
+	header := timetable.Header{SchoolName: h.Cfg.SchoolName, SchoolAddress: h.Cfg.SchoolAddress}
+	var buf bytes.Buffer
+	if err := timetable.Render(&buf, header, grids); err != nil {
+		fail(c, err)
+		return
+	}
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Writer.Write(buf.Bytes())
+}
+
+func (h *Handler) weekParam(c *gin.Context) (time.Time, bool) {
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		badRequest(c, "date required")
+		return time.Time{}, false
+	}
+	date, err := h.parseDate(dateStr)
+	if err != nil {
+		badRequest(c, "invalid date")
+		return time.Time{}, false
+	}
+	return calendar.WeekStart(date), true
+}
+
+func classLabel(s models.Schedule) string {
+	return fmt.Sprintf("%s\n%s", s.SubjectCode, s.TeacherName)
+}
+
+func teacherLabel(s models.Schedule) string {
+	return fmt.Sprintf("%s\n%s", s.SubjectCode, s.ClassCode)
+}
+
+func classTitle(rows []models.Schedule, classCode string) string {
+	for _, r := range rows {
+		if r.ClassName != "" {
+			return fmt.Sprintf("Jadwal Kelas %s (%s)", classCode, r.ClassName)
+		}
+	}
+	return fmt.Sprintf("Jadwal Kelas %s", classCode)
+}
+
+// ClassTimetablePDF prints the weekly grid of one class for the week
+// containing ?date=.
+func (h *Handler) ClassTimetablePDF(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	classCode := c.Query("class_code")
+	if classCode == "" {
+		badRequest(c, "class_code required")
+		return
+	}
+	week, ok := h.weekParam(c)
+	if !ok {
+		return
+	}
+	rows, err := h.weekRows(bson.M{"class_code": classCode}, week)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	grid := timetable.BuildGrid(classTitle(rows, classCode), week, rows, classLabel)
+	h.writePDF(c, fmt.Sprintf("jadwal_%s_%s.pdf", classCode, week.Format("20060102")), []timetable.Grid{grid})
+}
+
+// TeacherTimetablePDF prints the weekly grid of one teacher for the week
+// containing ?date=.
+func (h *Handler) TeacherTimetablePDF(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	nik := c.Query("teacher_nik")
+	if nik == "" {
+		badRequest(c, "teacher_nik required")
+		return
+	}
+	week, ok := h.weekParam(c)
+	if !ok {
+		return
+	}
+	rows, err := h.weekRows(bson.M{"teacher_nik": nik}, week)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	title := fmt.Sprintf("Jadwal Mengajar %s", nik)
+	if len(rows) > 0 && rows[0].TeacherName != "" {
+		title = fmt.Sprintf("Jadwal Mengajar %s (NIK %s)", rows[0].TeacherName, nik)
+	}
+	grid := timetable.BuildGrid(title, week, rows, teacherLabel)
+	h.writePDF(c, fmt.Sprintf("jadwal_guru_%s_%s.pdf", nik, week.Format("20060102")), []timetable.Grid{grid})
+}
+
+// AllClassesTimetablePDF bundles the weekly grid of every class that has
+// lessons in the week into one multi-page PDF, one class per page.
+func (h *Handler) AllClassesTimetablePDF(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	week, ok := h.weekParam(c)
+	if !ok {
+		return
+	}
+	rows, err := h.weekRows(bson.M{}, week)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	byClass := map[string][]models.Schedule{}
+	for _, r := range rows {
+		byClass[r.ClassCode] = append(byClass[r.ClassCode], r)
+	}
+	classes := make([]string, 0, len(byClass))
+	for cc := range byClass {
+		classes = append(classes, cc)
+	}
+	sort.Strings(classes)
+
+	grids := make([]timetable.Grid, 0, len(classes))
+	for _, cc := range classes {
+		grids = append(grids, timetable.BuildGrid(classTitle(byClass[cc], cc), week, byClass[cc], classLabel))
+	}
+	h.writePDF(c, fmt.Sprintf("jadwal_semua_kelas_%s.pdf", week.Format("20060102")), grids)
+}
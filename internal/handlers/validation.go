@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+// invalid answers 422 with the field errors of the request.
+func invalid(c *gin.Context, errs validate.Errors) {
+	problem.Write(c, http.StatusUnprocessableEntity, problem.CodeValidation, errs.Error(), gin.H{"fields": errs})
+}
+
+// bindingErrors turns the validator errors of a bound struct into field
+// errors named after its JSON tags. It reports false for other errors,
+// such as malformed JSON.
+func bindingErrors(obj interface{}, err error) (validate.Errors, bool) {
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return nil, false
+	}
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var errs validate.Errors
+	for _, fe := range ves {
+		name := fe.Field()
+		if f, ok := t.FieldByName(fe.StructField()); ok {
+			if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" {
+				name = tag
+			}
+		}
+		if fe.Tag() == "required" {
+			errs.Add(name, validate.CodeRequired, "is required")
+		} else {
+			errs.Add(name, validate.CodeFormat, "failed %s validation", fe.Tag())
+		}
+	}
+	return errs, true
+}
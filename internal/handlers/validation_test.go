@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func TestBindingErrorsUseJSONNames(t *testing.T) {
+	in := scheduleInput{ClassCode: "X-1", JamKe: 1}
+	err := binding.Validator.ValidateStruct(&in)
+
+	errs, ok := bindingErrors(&in, err)
+	assert.True(t, ok)
+	var names []string
+	for _, fe := range errs {
+		assert.Equal(t, validate.CodeRequired, fe.Code)
+		names = append(names, fe.Field)
+	}
+	assert.ElementsMatch(t, []string{
+		"class_name", "subject_code", "teacher_nik", "teacher_name", "date", "time_start", "time_end",
+	}, names)
+
+	_, ok = bindingErrors(&in, errors.New("unexpected EOF"))
+	assert.False(t, ok)
+}
+
+func TestCheckRate(t *testing.T) {
+	negative := -1.0
+	assert.Empty(t, checkRate(models.HonorariumRate{TeacherNIK: "123", RegularRate: 50000}))
+
+	errs := checkRate(models.HonorariumRate{RegularRate: -1, ExtraRate: &negative})
+	var fields []string
+	for _, fe := range errs {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"teacher_nik", "regular_rate", "extra_rate"}, fields)
+	assert.Len(t, checkRate(models.HonorariumRate{TeacherNIK: "123", EmploymentType: "GTT"}), 1)
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+	"github.com/mghazyfawazh/EGS/internal/webhook"
+)
+
+type webhookInput struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+// check validates the URL and event types of in.
+func (in webhookInput) check() validate.Errors {
+	var errs validate.Errors
+	if u, err := url.Parse(in.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.Add("url", validate.CodeFormat, "must be an absolute http or https URL, got %q", in.URL)
+	}
+	for _, e := range in.Events {
+		switch e {
+		case events.Created, events.Updated, events.Deleted:
+		default:
+			errs.Add("events", validate.CodeUnknown, "unknown event type %q", e)
+		}
+	}
+	return errs
+}
+
+// ListWebhooks lists the school's webhooks without their secrets.
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	rows, err := h.Webhooks.Find()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	for i := range rows {
+		rows[i].Secret = ""
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// AddWebhook subscribes a URL to schedule events. Without a secret one is
+// generated; the response is the only place it is shown.
+func (h *Handler) AddWebhook(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in webhookInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if errs := in.check(); len(errs) > 0 {
+		invalid(c, errs)
+		return
+	}
+	w := &models.Webhook{URL: in.URL, Events: in.Events, Secret: in.Secret, Active: in.Active == nil || *in.Active}
+	if w.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		w.Secret = secret
+	}
+	if err := h.Webhooks.Insert(w); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, w)
+}
+
+// webhookOf loads the webhook named by :id.
+func (h *Handler) webhookOf(c *gin.Context) (*models.Webhook, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return nil, false
+	}
+	w, err := h.Webhooks.Get(id)
+	if err != nil {
+		fail(c, err)
+		return nil, false
+	}
+	return w, true
+}
+
+func (h *Handler) GetWebhook(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	w, ok := h.webhookOf(c)
+	if !ok {
+		return
+	}
+	w.Secret = ""
+	c.JSON(http.StatusOK, w)
+}
+
+// SetWebhookActive pauses (active=false) or resumes a webhook. A paused
+// webhook gets no new deliveries, and its pending ones become dead
+// letters.
+func (h *Handler) SetWebhookActive(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var in struct {
+		Active *bool `json:"active" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	w, ok := h.webhookOf(c)
+	if !ok {
+		return
+	}
+	if err := h.Webhooks.SetActive(w.ID, *in.Active); err != nil {
+		fail(c, err)
+		return
+	}
+	w.Active, w.Secret = *in.Active, ""
+	c.JSON(http.StatusOK, w)
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	w, ok := h.webhookOf(c)
+	if !ok {
+		return
+	}
+	if err := h.Webhooks.Delete(w.ID); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// PingWebhook queues a ping delivery, to check a receiver and its
+// signature verification.
+func (h *Handler) PingWebhook(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	w, ok := h.webhookOf(c)
+	if !ok {
+		return
+	}
+	d, err := h.Dispatcher.SendPing(*w)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
+
+// ListDeliveries is the delivery log, newest first, optionally of one
+// ?webhook_id= and ?status=. ?status=dead lists the dead letters.
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	var webhookID *primitive.ObjectID
+	if v := c.Query("webhook_id"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			badRequest(c, "invalid webhook_id")
+			return
+		}
+		webhookID = &id
+	}
+	h.deliveries(c, webhookID, c.Query("status"))
+}
+
+// WebhookDeliveries is the delivery log of one webhook.
+func (h *Handler) WebhookDeliveries(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	w, ok := h.webhookOf(c)
+	if !ok {
+		return
+	}
+	h.deliveries(c, &w.ID, c.Query("status"))
+}
+
+// DeadLetters lists the deliveries that used up their attempts.
+func (h *Handler) DeadLetters(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	h.deliveries(c, nil, models.DeliveryDead)
+}
+
+func (h *Handler) deliveries(c *gin.Context, webhookID *primitive.ObjectID, status string) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	rows, err := h.Webhooks.FindDeliveries(webhookID, status, int64(limit))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// RetryDelivery queues a dead letter again for one more attempt.
+func (h *Handler) RetryDelivery(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+	d, err := h.Webhooks.Retry(id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	h.Dispatcher.Wake()
+	c.JSON(http.StatusAccepted, d)
+}
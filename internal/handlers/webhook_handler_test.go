@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+)
+
+func TestWebhookInputCheck(t *testing.T) {
+	ok := webhookInput{URL: "http://localhost:9000/hook", Events: []string{events.Created, events.Deleted}}
+	assert.Empty(t, ok.check())
+
+	bad := webhookInput{URL: "localhost:9000/hook", Events: []string{"schedule.moved"}}
+	errs := bad.check()
+	if assert.Len(t, errs, 2) {
+		assert.Equal(t, "url", errs[0].Field)
+		assert.Equal(t, "events", errs[1].Field)
+	}
+	assert.Len(t, webhookInput{URL: "ftp://example.com/x"}.check(), 1)
+}
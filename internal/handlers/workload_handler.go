@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/workload"
+)
+
+// Workload lists every teacher's weekly JP for a date range against their
+// limits, with the under- and over-loaded teachers singled out.
+func (h *Handler) Workload(c *gin.Context) {
+	if !authorize(c) {
+		return
+	}
+	h = h.tenant(c)
+	sd := c.Query("start_date")
+	ed := c.Query("end_date")
+	if sd == "" || ed == "" {
+		badRequest(c, "start_date and end_date required")
+		return
+	}
+	start, err := h.parseDate(sd)
+	if err != nil {
+		badRequest(c, "invalid start_date")
+		return
+	}
+	end, err := h.parseDate(ed)
+	if err != nil {
+		badRequest(c, "invalid end_date")
+		return
+	}
+	if end.Before(start) {
+		badRequest(c, "end_date must not be before start_date")
+		return
+	}
+
+	cur, err := h.Coll.Find(h.Ctx, h.Repo.Scope(bson.M{"date": bson.M{"$gte": start, "$lte": end}}))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		fail(c, err)
+		return
+	}
+	profiles, err := h.Teachers.FindAll()
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	loads := workload.Dashboard(rows, profiles, h.JP, h.defaultLimits(), start, end)
+	under := []workload.TeacherLoad{}
+	over := []workload.TeacherLoad{}
+	for _, tl := range loads {
+		switch tl.Status {
+		case workload.StatusUnder:
+			under = append(under, tl)
+		case workload.StatusOver:
+			over = append(over, tl)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":     start.Format("2006-01-02"),
+		"end_date":       end.Format("2006-01-02"),
+		"default_limits": h.defaultLimits(),
+		"underloaded":    under,
+		"overloaded":     over,
+		"teachers":       loads,
+	})
+}
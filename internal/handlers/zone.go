@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"github.com/mghazyfawazh/EGS/internal/tz"
+)
+
+// parseDate reads a date parameter as a calendar day at the school.
+func (h *Handler) parseDate(s string) (time.Time, error) {
+	return tz.ParseDate(s, h.Zone)
+}
+
+// today is the current calendar day at the school.
+func (h *Handler) today() time.Time {
+	return tz.Today(h.Zone)
+}
+
+// stamp sets the StartAt/EndAt instants of s from its day and clock times
+// and links s to the term of its day. Times that do not parse leave the
+// instants zero; validation rejects them before anything is saved. It
+// fails when the school's terms cannot be read.
+func (h *Handler) stamp(s *models.Schedule) error {
+	s.StartAt, _ = tz.At(s.Date, s.TimeStart, h.Zone)
+	s.EndAt, _ = tz.At(s.Date, s.TimeEnd, h.Zone)
+	term, err := h.termOf(s.Date)
+	if err != nil {
+		return err
+	}
+	s.TermID = term
+	return nil
+}
+
+// BackfillInstants stamps lessons saved before StartAt/EndAt existed, each
+// in the zone of its own school.
+func (h *Handler) BackfillInstants() error {
+	cur, err := h.Coll.Find(h.Ctx, bson.M{"start_at": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	var rows []models.Schedule
+	if err := cur.All(h.Ctx, &rows); err != nil {
+		return err
+	}
+	schools := map[string]*Handler{}
+	for i := range rows {
+		s := &rows[i]
+		if s.SchoolID == "" {
+			s.SchoolID = tenant.Default
+		}
+		hs, ok := schools[s.SchoolID]
+		if !ok {
+			hs = h.forSchool(s.SchoolID)
+			schools[s.SchoolID] = hs
+		}
+		if err := hs.stamp(s); err != nil {
+			return err
+		}
+		if err := hs.Repo.UpdateByUUID(s.UUID, bson.M{"start_at": s.StartAt, "end_at": s.EndAt}); err != nil {
+			return err
+		}
+	}
+	if len(rows) > 0 {
+		log.Printf("stamped %d lessons of %d schools with start_at/end_at", len(rows), len(schools))
+	}
+	return nil
+}
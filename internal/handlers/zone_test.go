@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tz"
+)
+
+func TestTimeOverlapAcceptsShortClock(t *testing.T) {
+	overlap, err := timeOverlap("07:00", "07:45", "07:30:00", "08:15:00")
+	require.NoError(t, err)
+	assert.True(t, overlap)
+
+	overlap, err = timeOverlap("07:00", "07:45", "08:00", "08:45")
+	require.NoError(t, err)
+	assert.False(t, overlap)
+}
+
+func TestStampUsesSchoolZone(t *testing.T) {
+	wita, err := tz.Load("Asia/Makassar")
+	require.NoError(t, err)
+	h := &Handler{Zone: wita, termsLoaded: true}
+
+	s := models.Schedule{
+		Date:      time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		TimeStart: "07:00",
+		TimeEnd:   "07:45:00",
+	}
+	require.NoError(t, h.stamp(&s))
+	assert.Equal(t, time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC), s.StartAt.UTC())
+	assert.Equal(t, time.Date(2024, 3, 3, 23, 45, 0, 0, time.UTC), s.EndAt.UTC())
+
+	d, err := h.parseDate("2024-03-03T23:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, s.Date, d)
+}
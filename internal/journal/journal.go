@@ -0,0 +1,80 @@
+// Package journal sets the planned JP of teachers against what the
+// teaching journal records as delivered, cancelled or substituted.
+package journal
+
+import (
+	"sort"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Totals are the JP of one teacher. PlannedJP covers the teacher's own
+// lessons and splits into what the teacher delivered, cancelled, handed to
+// a substitute and has not recorded yet. DeliveredJP also includes
+// SubstituteJP, the lessons of others the teacher took.
+type Totals struct {
+	TeacherNIK    string  `json:"teacher_nik"`
+	TeacherName   string  `json:"teacher_name"`
+	Lessons       int     `json:"lessons"`
+	PlannedJP     float64 `json:"planned_jp"`
+	DeliveredJP   float64 `json:"delivered_jp"`
+	CancelledJP   float64 `json:"cancelled_jp"`
+	SubstitutedJP float64 `json:"substituted_jp"`
+	SubstituteJP  float64 `json:"substitute_jp"`
+	UnrecordedJP  float64 `json:"unrecorded_jp"`
+}
+
+// ByTeacher totals rows per teacher, sorted by NIK. Substitutes appear
+// even without lessons of their own in rows.
+func ByTeacher(rows []models.Schedule, calc jp.Calculator, j models.Journal) []Totals {
+	teachers := map[string]*Totals{}
+	get := func(nik, name string) *Totals {
+		t, ok := teachers[nik]
+		if !ok {
+			t = &Totals{TeacherNIK: nik}
+			teachers[nik] = t
+		}
+		if name != "" {
+			t.TeacherName = name
+		}
+		return t
+	}
+
+	for _, s := range rows {
+		v := calc.Of(s)
+		t := get(s.TeacherNIK, s.TeacherName)
+		t.Lessons++
+		t.PlannedJP += v
+		switch j.Status(s) {
+		case models.JournalDelivered:
+			t.DeliveredJP += v
+		case models.JournalCancelled:
+			t.CancelledJP += v
+		case models.JournalSubstituted:
+			t.SubstitutedJP += v
+			sub := get(j.TaughtBy(s))
+			sub.SubstituteJP += v
+			sub.DeliveredJP += v
+		default:
+			t.UnrecordedJP += v
+		}
+	}
+
+	out := make([]Totals, 0, len(teachers))
+	for _, t := range teachers {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].TeacherNIK < out[b].TeacherNIK })
+	return out
+}
+
+// Of returns the totals of nik, zero when nik has none.
+func Of(totals []Totals, nik string) Totals {
+	for _, t := range totals {
+		if t.TeacherNIK == nik {
+			return t
+		}
+	}
+	return Totals{TeacherNIK: nik}
+}
@@ -0,0 +1,41 @@
+package journal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestByTeacher(t *testing.T) {
+	calc := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lesson := func(uuid, nik, name, end string) models.Schedule {
+		return models.Schedule{UUID: uuid, TeacherNIK: nik, TeacherName: name, Date: day, TimeStart: "07:00:00", TimeEnd: end}
+	}
+	rows := []models.Schedule{
+		lesson("a", "1", "Budi", "08:30:00"),
+		lesson("b", "1", "Budi", "07:45:00"),
+		lesson("c", "1", "Budi", "07:45:00"),
+		lesson("d", "1", "Budi", "08:30:00"),
+		lesson("e", "2", "Sari", "07:45:00"),
+	}
+	j := models.NewJournal([]models.JournalEntry{
+		{ScheduleUUID: "a", Status: models.JournalDelivered},
+		{ScheduleUUID: "b", Status: models.JournalCancelled},
+		{ScheduleUUID: "c", Status: models.JournalSubstituted, SubstituteNIK: "3", SubstituteName: "Joko"},
+	})
+
+	totals := ByTeacher(rows, calc, j)
+	assert.Equal(t, []Totals{
+		{TeacherNIK: "1", TeacherName: "Budi", Lessons: 4, PlannedJP: 6, DeliveredJP: 2, CancelledJP: 1, SubstitutedJP: 1, UnrecordedJP: 2},
+		{TeacherNIK: "2", TeacherName: "Sari", Lessons: 1, PlannedJP: 1, UnrecordedJP: 1},
+		{TeacherNIK: "3", TeacherName: "Joko", DeliveredJP: 1, SubstituteJP: 1},
+	}, totals)
+
+	assert.Equal(t, 1.0, Of(totals, "3").DeliveredJP)
+	assert.Equal(t, Totals{TeacherNIK: "9"}, Of(totals, "9"))
+}
@@ -0,0 +1,100 @@
+package jp
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Rounding rules for converting lesson minutes into JP.
+const (
+	RoundNearest = "nearest" // 30 of 45 minutes counts as 1 JP, 20 as 0
+	RoundUp      = "up"      // any started JP counts as a full one
+	RoundDown    = "down"    // only completed JP count
+	RoundHalf    = "half"    // nearest half JP
+	RoundNone    = "none"    // exact fraction, two decimals
+)
+
+const DefaultLessonMinutes = 45
+
+// ValidRounding reports whether rounding names a rounding rule; empty
+// means the default, RoundNearest.
+func ValidRounding(rounding string) bool {
+	switch rounding {
+	case "", RoundNearest, RoundUp, RoundDown, RoundHalf, RoundNone:
+		return true
+	}
+	return false
+}
+
+// Calculator converts lesson durations into JP (jam pelajaran).
+type Calculator struct {
+	LessonMinutes int
+	Rounding      string
+}
+
+func ParseClock(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse("15:04:05", s); err == nil {
+		return t, nil
+	}
+	return time.Parse("15:04", s)
+}
+
+// Minutes returns the duration between two HH:MM[:SS] clock times.
+func Minutes(timeStart, timeEnd string) (int, error) {
+	start, err := ParseClock(timeStart)
+	if err != nil {
+		return 0, err
+	}
+	end, err := ParseClock(timeEnd)
+	if err != nil {
+		return 0, err
+	}
+	if !end.After(start) {
+		return 0, nil
+	}
+	return int(end.Sub(start).Minutes()), nil
+}
+
+// FromMinutes applies the rounding rule to a number of lesson minutes.
+func (c Calculator) FromMinutes(minutes int) float64 {
+	length := c.LessonMinutes
+	if length <= 0 {
+		length = DefaultLessonMinutes
+	}
+	raw := float64(minutes) / float64(length)
+	switch c.Rounding {
+	case RoundUp:
+		return math.Ceil(raw - 1e-9)
+	case RoundDown:
+		return math.Floor(raw + 1e-9)
+	case RoundHalf:
+		return math.Round(raw*2) / 2
+	case RoundNone:
+		return math.Round(raw*100) / 100
+	default:
+		return math.Round(raw)
+	}
+}
+
+// Of returns the JP of a single lesson. Rows whose times cannot be parsed
+// count as one JP, which is what reports did before durations were used.
+func (c Calculator) Of(s models.Schedule) float64 {
+	m, err := Minutes(s.TimeStart, s.TimeEnd)
+	if err != nil {
+		return 1
+	}
+	return c.FromMinutes(m)
+}
+
+// Total sums the JP of every lesson, rounding each lesson on its own.
+func (c Calculator) Total(rows []models.Schedule) float64 {
+	total := 0.0
+	for _, r := range rows {
+		total += c.Of(r)
+	}
+	return total
+}
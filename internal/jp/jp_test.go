@@ -0,0 +1,64 @@
+package jp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestMinutes(t *testing.T) {
+	m, err := jp.Minutes("07:00:00", "08:30:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 90, m)
+
+	m, err = jp.Minutes("07:00", "07:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 30, m)
+
+	_, err = jp.Minutes("7 pagi", "08:00")
+	assert.Error(t, err)
+}
+
+func TestFromMinutes(t *testing.T) {
+	cases := []struct {
+		rounding string
+		minutes  int
+		want     float64
+	}{
+		{jp.RoundNearest, 45, 1},
+		{jp.RoundNearest, 90, 2},
+		{jp.RoundNearest, 30, 1},
+		{jp.RoundNearest, 20, 0},
+		{jp.RoundUp, 50, 2},
+		{jp.RoundUp, 90, 2},
+		{jp.RoundDown, 80, 1},
+		{jp.RoundHalf, 30, 0.5},
+		{jp.RoundNone, 30, 0.67},
+		{"", 45, 1},
+	}
+	for _, tc := range cases {
+		c := jp.Calculator{LessonMinutes: 45, Rounding: tc.rounding}
+		assert.Equal(t, tc.want, c.FromMinutes(tc.minutes), "%s %d", tc.rounding, tc.minutes)
+	}
+}
+
+func TestValidRounding(t *testing.T) {
+	for _, r := range []string{"", jp.RoundNearest, jp.RoundUp, jp.RoundDown, jp.RoundHalf, jp.RoundNone} {
+		assert.True(t, jp.ValidRounding(r), r)
+	}
+	assert.False(t, jp.ValidRounding("UP"))
+	assert.False(t, jp.ValidRounding("ceil"))
+}
+
+func TestTotal(t *testing.T) {
+	c := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	rows := []models.Schedule{
+		{TimeStart: "07:00:00", TimeEnd: "08:30:00"},
+		{TimeStart: "08:30:00", TimeEnd: "09:00:00"},
+		{TimeStart: "bad", TimeEnd: "09:00:00"},
+	}
+	assert.Equal(t, 4.0, c.Total(rows))
+}
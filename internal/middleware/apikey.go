@@ -2,22 +2,23 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/mghazyfawazh/EGS/internal/problem"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
 )
 
+// APIKeyAuth rejects requests without a known x-api-key and stores the
+// school the key belongs to under tenant.Key.
 func APIKeyAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := c.GetHeader("x-api-key")
-		expected := os.Getenv("API_KEY")
-		if expected == "" {
-			expected = "SECRET123"
-		}
-		if key == "" || key != expected {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		school, ok := tenant.FromEnv().Resolve(c.GetHeader("x-api-key"))
+		if !ok {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid x-api-key", nil)
 			return
 		}
+		c.Set(tenant.Key, school)
 		c.Next()
 	}
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attendance statuses, as on the school's attendance sheets.
+const (
+	AttendanceHadir = "hadir" // present
+	AttendanceIzin  = "izin"  // excused
+	AttendanceSakit = "sakit" // sick
+	AttendanceAlpa  = "alpa"  // absent without notice
+)
+
+func ValidAttendance(s string) bool {
+	return s == AttendanceHadir || s == AttendanceIzin || s == AttendanceSakit || s == AttendanceAlpa
+}
+
+// Attendance is one student's presence at one lesson. The lesson's class,
+// subject, day and term are copied in when it is recorded, so reports do
+// not need to join the schedules.
+type Attendance struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	SchoolID     string              `bson:"school_id" json:"-"`
+	ScheduleUUID string              `bson:"schedule_uuid" json:"schedule_uuid"`
+	ClassCode    string              `bson:"class_code" json:"class_code"`
+	SubjectCode  string              `bson:"subject_code" json:"subject_code"`
+	Date         time.Time           `bson:"date" json:"date"`
+	TermID       *primitive.ObjectID `bson:"term_id" json:"term_id"`
+	StudentNIS   string              `bson:"student_nis" json:"student_nis"`
+	StudentName  string              `bson:"student_name" json:"student_name"`
+	Status       string              `bson:"status" json:"status"`
+	Note         string              `bson:"note,omitempty" json:"note,omitempty"`
+	// TeacherNIK is who recorded it.
+	TeacherNIK string    `bson:"teacher_nik" json:"teacher_nik"`
+	RecordedAt time.Time `bson:"recorded_at" json:"recorded_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records one batch change, e.g. a bulk delete, with the filter
+// it was run with and the lessons it touched.
+type AuditEntry struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	SchoolID   string                 `bson:"school_id" json:"-"`
+	Action     string                 `bson:"action" json:"action"`
+	Filter     map[string]interface{} `bson:"filter" json:"filter"`
+	Params     map[string]interface{} `bson:"params,omitempty" json:"params,omitempty"`
+	Count      int                    `bson:"count" json:"count"`
+	UUIDs      []string               `bson:"uuids" json:"uuids"`
+	RemoteAddr string                 `bson:"remote_addr,omitempty" json:"remote_addr,omitempty"`
+	CreatedAt  time.Time              `bson:"created_at" json:"created_at"`
+}
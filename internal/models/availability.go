@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AvailabilityWindow is a recurring slot in which a teacher can teach.
+// Weekday follows ISO numbering (1 = Monday .. 7 = Sunday). A zero
+// JamKeFrom/JamKeTo leaves that end of the period range open. Teachers
+// without any window are available on every day.
+type AvailabilityWindow struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID   string             `bson:"school_id" json:"-"`
+	TeacherNIK string             `bson:"teacher_nik" json:"teacher_nik"`
+	Weekday    int                `bson:"weekday" json:"weekday"`
+	JamKeFrom  int                `bson:"jam_ke_from" json:"jam_ke_from"`
+	JamKeTo    int                `bson:"jam_ke_to" json:"jam_ke_to"`
+	Note       string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Unavailability is a one-off absence such as leave or training. Both dates
+// are inclusive.
+type Unavailability struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID   string             `bson:"school_id" json:"-"`
+	TeacherNIK string             `bson:"teacher_nik" json:"teacher_nik"`
+	StartDate  time.Time          `bson:"start_date" json:"start_date"`
+	EndDate    time.Time          `bson:"end_date" json:"end_date"`
+	Reason     string             `bson:"reason" json:"reason"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
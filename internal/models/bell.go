@@ -0,0 +1,25 @@
+package models
+
+// BellPeriod is one period (jam ke-N) of the school's bell schedule.
+// Weekdays uses ISO numbering (1 = Monday); an empty list means Monday to
+// Saturday, so a shorter Friday can be modelled with its own periods.
+type BellPeriod struct {
+	SchoolID  string `bson:"school_id" json:"-"`
+	JamKe     int    `bson:"jam_ke" json:"jam_ke"`
+	TimeStart string `bson:"time_start" json:"time_start"`
+	TimeEnd   string `bson:"time_end" json:"time_end"`
+	Weekdays  []int  `bson:"weekdays,omitempty" json:"weekdays,omitempty"`
+}
+
+// On reports whether the period is rung on the given ISO weekday.
+func (p BellPeriod) On(weekday int) bool {
+	if len(p.Weekdays) == 0 {
+		return weekday >= 1 && weekday <= 6
+	}
+	for _, d := range p.Weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of change a teacher may request for a lesson.
+const (
+	ChangeMove   = "move"
+	ChangeSwap   = "swap"
+	ChangeCancel = "cancel"
+)
+
+// Change request states.
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestRejected = "rejected"
+)
+
+// ChangeRequest is a teacher's proposal to move, swap or cancel one of
+// their lessons. It is pre-checked when submitted and applied only when an
+// admin approves it.
+type ChangeRequest struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID     string             `bson:"school_id" json:"-"`
+	TeacherNIK   string             `bson:"teacher_nik" json:"teacher_nik"`
+	ScheduleUUID string             `bson:"schedule_uuid" json:"schedule_uuid"`
+	Kind         string             `bson:"kind" json:"kind"`
+	Reason       string             `bson:"reason" json:"reason"`
+
+	// Target slot of a move; the times default to the bell schedule.
+	Date      *time.Time `bson:"date,omitempty" json:"date,omitempty"`
+	JamKe     int        `bson:"jam_ke,omitempty" json:"jam_ke,omitempty"`
+	TimeStart string     `bson:"time_start,omitempty" json:"time_start,omitempty"`
+	TimeEnd   string     `bson:"time_end,omitempty" json:"time_end,omitempty"`
+	// SwapUUID is the lesson a swap exchanges slots with.
+	SwapUUID string `bson:"swap_uuid,omitempty" json:"swap_uuid,omitempty"`
+
+	// Warnings found by the pre-check, e.g. workload limits.
+	Warnings []string `bson:"warnings,omitempty" json:"warnings,omitempty"`
+
+	Status     string     `bson:"status" json:"status"`
+	ReviewNote string     `bson:"review_note,omitempty" json:"review_note,omitempty"`
+	ReviewedAt *time.Time `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
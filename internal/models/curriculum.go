@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CurriculumRequirement is how many JP of a subject a class must receive.
+// JPPerWeek is checked against every school week of a range; JPPerSemester
+// is the total for a semester-long range.
+type CurriculumRequirement struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID      string             `bson:"school_id" json:"-"`
+	ClassCode     string             `bson:"class_code" json:"class_code"`
+	SubjectCode   string             `bson:"subject_code" json:"subject_code"`
+	JPPerWeek     float64            `bson:"jp_per_week" json:"jp_per_week"`
+	JPPerSemester float64            `bson:"jp_per_semester" json:"jp_per_semester"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Draft states.
+const (
+	DraftOpen      = "open"
+	DraftPublished = "published"
+	DraftDiscarded = "discarded"
+)
+
+// Draft is an unpublished version of the timetable of a date range. It
+// starts as a copy of the published lessons of the range; edits made in
+// the draft stay invisible until the draft is published as a whole.
+type Draft struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID    string             `bson:"school_id" json:"-"`
+	Name        string             `bson:"name" json:"name"`
+	StartDate   time.Time          `bson:"start_date" json:"start_date"`
+	EndDate     time.Time          `bson:"end_date" json:"end_date"`
+	Status      string             `bson:"status" json:"status"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	PublishedAt *time.Time         `bson:"published_at,omitempty" json:"published_at,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Holiday is a school-wide day off, such as a national holiday or a
+// semester break. Both dates are inclusive.
+type Holiday struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID  string             `bson:"school_id" json:"-"`
+	StartDate time.Time          `bson:"start_date" json:"start_date"`
+	EndDate   time.Time          `bson:"end_date" json:"end_date"`
+	Name      string             `bson:"name" json:"name"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
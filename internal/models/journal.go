@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Journal statuses: how a lesson actually went (jurnal mengajar).
+const (
+	JournalDelivered   = "delivered"
+	JournalCancelled   = "cancelled"
+	JournalSubstituted = "substituted"
+)
+
+func ValidJournal(s string) bool {
+	return s == JournalDelivered || s == JournalCancelled || s == JournalSubstituted
+}
+
+// JournalEntry records whether a lesson took place and what was taught.
+// The lesson's class, subject, teacher, day and term are copied in when it
+// is recorded. They go stale when the lesson is moved or swapped later, so
+// reports go by the lesson itself, see For and Journal.Sync.
+type JournalEntry struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	SchoolID     string              `bson:"school_id" json:"-"`
+	ScheduleUUID string              `bson:"schedule_uuid" json:"schedule_uuid"`
+	ClassCode    string              `bson:"class_code" json:"class_code"`
+	SubjectCode  string              `bson:"subject_code" json:"subject_code"`
+	TeacherNIK   string              `bson:"teacher_nik" json:"teacher_nik"`
+	Date         time.Time           `bson:"date" json:"date"`
+	TermID       *primitive.ObjectID `bson:"term_id" json:"term_id"`
+	Status       string              `bson:"status" json:"status"`
+	Topic        string              `bson:"topic,omitempty" json:"topic,omitempty"`
+	Note         string              `bson:"note,omitempty" json:"note,omitempty"`
+	// SubstituteNIK taught the lesson in place of TeacherNIK when Status
+	// is substituted.
+	SubstituteNIK  string `bson:"substitute_nik,omitempty" json:"substitute_nik,omitempty"`
+	SubstituteName string `bson:"substitute_name,omitempty" json:"substitute_name,omitempty"`
+	// RecordedBy is who recorded it.
+	RecordedBy string    `bson:"recorded_by" json:"recorded_by"`
+	RecordedAt time.Time `bson:"recorded_at" json:"recorded_at"`
+}
+
+// For returns e with the lesson fields taken from its lesson s.
+func (e JournalEntry) For(s Schedule) JournalEntry {
+	e.ClassCode, e.SubjectCode, e.TeacherNIK = s.ClassCode, s.SubjectCode, s.TeacherNIK
+	e.Date, e.TermID = s.Date, s.TermID
+	return e
+}
+
+// Journal maps lesson UUIDs to their journal entries. A lesson without an
+// entry has not been recorded yet and counts as not delivered.
+type Journal map[string]JournalEntry
+
+func NewJournal(entries []JournalEntry) Journal {
+	j := make(Journal, len(entries))
+	for _, e := range entries {
+		j[e.ScheduleUUID] = e
+	}
+	return j
+}
+
+// Status is the recorded status of s, or "" when it was not recorded.
+func (j Journal) Status(s Schedule) string {
+	return j[s.UUID].Status
+}
+
+// Delivered reports whether s took place, by its own teacher or a
+// substitute.
+func (j Journal) Delivered(s Schedule) bool {
+	st := j.Status(s)
+	return st == JournalDelivered || st == JournalSubstituted
+}
+
+// TaughtBy returns the teacher who took s: the substitute when there was
+// one, otherwise the lesson's teacher.
+func (j Journal) TaughtBy(s Schedule) (nik, name string) {
+	if e, ok := j[s.UUID]; ok && e.Status == JournalSubstituted && e.SubstituteNIK != "" {
+		return e.SubstituteNIK, e.SubstituteName
+	}
+	return s.TeacherNIK, s.TeacherName
+}
+
+// Sync refreshes the entries of rows from the lessons, see For.
+func (j Journal) Sync(rows []Schedule) {
+	for _, s := range rows {
+		if e, ok := j[s.UUID]; ok {
+			j[s.UUID] = e.For(s)
+		}
+	}
+}
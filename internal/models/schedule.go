@@ -6,8 +6,13 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Schedule is one lesson. Date is the lesson's calendar day at the school,
+// stored as UTC midnight; TimeStart and TimeEnd are the wall-clock times
+// shown on timetables. StartAt and EndAt are the same times as instants in
+// the school's zone, for clients that need absolute times.
 type Schedule struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID    string             `bson:"school_id" json:"-"`
 	UUID        string             `bson:"uuid" json:"uuid"`
 	ClassCode   string             `bson:"class_code" json:"class_code"`
 	ClassName   string             `bson:"class_name" json:"class_name"`
@@ -18,6 +23,29 @@ type Schedule struct {
 	JamKe       int                `bson:"jam_ke" json:"jam_ke"`       
 	TimeStart   string             `bson:"time_start" json:"time_start"` 
 	TimeEnd     string             `bson:"time_end" json:"time_end"`
+	LessonType  string             `bson:"lesson_type,omitempty" json:"lesson_type,omitempty"`
+	StartAt     time.Time          `bson:"start_at" json:"start_at"`
+	EndAt       time.Time          `bson:"end_at" json:"end_at"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// TermID links the lesson to the term containing Date, if any.
+	TermID *primitive.ObjectID `bson:"term_id" json:"term_id"`
+	// DraftID is set on the copies of lessons held by a draft.
+	DraftID *primitive.ObjectID `bson:"draft_id,omitempty" json:"draft_id,omitempty"`
+
+	// Warnings are returned to the client with a saved lesson (e.g. a
+	// workload limit was exceeded) and never stored.
+	Warnings []string `bson:"-" json:"warnings,omitempty"`
+}
+
+// Lesson types. An empty LessonType is a regular lesson.
+const (
+	LessonRegular    = "regular"
+	LessonExtra      = "extra"
+	LessonSubstitute = "substitute"
+)
+
+func ValidLessonType(t string) bool {
+	return t == "" || t == LessonRegular || t == LessonExtra || t == LessonSubstitute
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// School holds the settings of one school (tenant). Zero values fall back
+// to the deployment-wide defaults from the environment.
+type School struct {
+	ID                  string    `bson:"_id" json:"id"`
+	Name                string    `bson:"name" json:"name"`
+	Address             string    `bson:"address" json:"address"`
+	Timezone            string    `bson:"timezone" json:"timezone"`
+	JPMinutes           int       `bson:"jp_minutes" json:"jp_minutes"`
+	JPRounding          string    `bson:"jp_rounding" json:"jp_rounding"`
+	WorkloadMinWeeklyJP float64   `bson:"workload_min_weekly_jp" json:"workload_min_weekly_jp"`
+	WorkloadMaxWeeklyJP float64   `bson:"workload_max_weekly_jp" json:"workload_max_weekly_jp"`
+	WorkloadMaxDailyJP  float64   `bson:"workload_max_daily_jp" json:"workload_max_daily_jp"`
+	WorkloadMode        string    `bson:"workload_mode" json:"workload_mode"`
+	NIKPattern          string    `bson:"nik_pattern" json:"nik_pattern"`
+	UpdatedAt           time.Time `bson:"updated_at" json:"updated_at"`
+}
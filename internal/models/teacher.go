@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Teacher is the profile of a teacher, keyed by NIK. Lessons still carry
+// the teacher's NIK and name themselves; the profile holds what lessons
+// cannot, such as the employment type used for honorarium rates and the
+// workload limits in JP (zero falls back to the school default). Email is
+// where change notices and the daily digest go; see package notify.
+type Teacher struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID       string             `bson:"school_id" json:"-"`
+	NIK            string             `bson:"nik" json:"nik"`
+	Name           string             `bson:"name" json:"name"`
+	Email          string             `bson:"email,omitempty" json:"email,omitempty"`
+	EmploymentType string             `bson:"employment_type" json:"employment_type"`
+	MinWeeklyJP    float64            `bson:"min_weekly_jp" json:"min_weekly_jp"`
+	MaxWeeklyJP    float64            `bson:"max_weekly_jp" json:"max_weekly_jp"`
+	MaxDailyJP     float64            `bson:"max_daily_jp" json:"max_daily_jp"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// HonorariumRate is the pay per JP for either one teacher (TeacherNIK set)
+// or every teacher of an employment type (EmploymentType set). Extra and
+// substitute rates that are not set fall back to the regular rate; a rate
+// set to zero pays nothing.
+type HonorariumRate struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID       string             `bson:"school_id" json:"-"`
+	TeacherNIK     string             `bson:"teacher_nik,omitempty" json:"teacher_nik,omitempty"`
+	EmploymentType string             `bson:"employment_type,omitempty" json:"employment_type,omitempty"`
+	RegularRate    float64            `bson:"regular_rate" json:"regular_rate"`
+	ExtraRate      *float64           `bson:"extra_rate,omitempty" json:"extra_rate"`
+	SubstituteRate *float64           `bson:"substitute_rate,omitempty" json:"substitute_rate"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AcademicYear is a school year such as "2024/2025". Both dates are
+// inclusive.
+type AcademicYear struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID  string             `bson:"school_id" json:"-"`
+	Name      string             `bson:"name" json:"name"`
+	StartDate time.Time          `bson:"start_date" json:"start_date"`
+	EndDate   time.Time          `bson:"end_date" json:"end_date"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Term is a semester of an academic year. Lessons dated within a term are
+// linked to it; a locked term is archived and its lessons are read-only.
+type Term struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID       string             `bson:"school_id" json:"-"`
+	AcademicYearID primitive.ObjectID `bson:"academic_year_id" json:"academic_year_id"`
+	Name           string             `bson:"name" json:"name"`
+	Semester       int                `bson:"semester" json:"semester"`
+	StartDate      time.Time          `bson:"start_date" json:"start_date"`
+	EndDate        time.Time          `bson:"end_date" json:"end_date"`
+	Locked         bool               `bson:"locked" json:"locked"`
+	LockedAt       *time.Time         `bson:"locked_at,omitempty" json:"locked_at,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
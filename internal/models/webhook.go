@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook delivery states. A delivery that fails its last attempt is dead
+// and stays in the dead-letter list until it is retried by hand.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook subscribes a URL to schedule events of the school. Payloads are
+// signed with Secret; see package webhook.
+type Webhook struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID string             `bson:"school_id" json:"-"`
+	URL      string             `bson:"url" json:"url"`
+	// Events lists the event types to send; empty means all of them.
+	Events    []string  `bson:"events" json:"events"`
+	Secret    string    `bson:"secret" json:"secret,omitempty"`
+	Active    bool      `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one webhook.
+type WebhookDelivery struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SchoolID  string             `bson:"school_id" json:"-"`
+	WebhookID primitive.ObjectID `bson:"webhook_id" json:"webhook_id"`
+	EventType string             `bson:"event_type" json:"event_type"`
+	// Payload is the JSON body, kept as sent so retries carry the same
+	// bytes and signature input.
+	Payload string `bson:"payload" json:"payload"`
+
+	Status        string            `bson:"status" json:"status"`
+	Attempts      []DeliveryAttempt `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time         `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time         `bson:"created_at" json:"created_at"`
+	DeliveredAt   *time.Time        `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+}
+
+// DeliveryAttempt records one POST to the webhook URL.
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// Wants reports whether w subscribes to events of type typ.
+func (w Webhook) Wants(typ string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
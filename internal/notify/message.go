@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+var (
+	dayNames   = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+	monthNames = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli",
+		"Agustus", "September", "Oktober", "November", "Desember"}
+)
+
+// FormatDay writes a lesson day the Indonesian way, e.g. "Selasa, 13
+// Agustus 2024".
+func FormatDay(d time.Time) string {
+	return fmt.Sprintf("%s, %d %s %d", dayNames[d.Weekday()], d.Day(), monthNames[d.Month()-1], d.Year())
+}
+
+// describe is one lesson on one line.
+func describe(s *models.Schedule) string {
+	class := s.ClassCode
+	if s.ClassName != "" {
+		class += " (" + s.ClassName + ")"
+	}
+	return fmt.Sprintf("%s, jam ke-%d (%s-%s), kelas %s, %s",
+		FormatDay(s.Date), s.JamKe, s.TimeStart, s.TimeEnd, class, s.SubjectCode)
+}
+
+// Affected returns the NIKs of the teachers an event concerns: the
+// lesson's teacher and, when an update handed it to someone else, the
+// previous one.
+func Affected(e events.Event) []string {
+	var out []string
+	if e.Schedule != nil && e.Schedule.TeacherNIK != "" {
+		out = append(out, e.Schedule.TeacherNIK)
+	}
+	if e.Previous != nil && e.Previous.TeacherNIK != "" && (e.Schedule == nil || e.Previous.TeacherNIK != e.Schedule.TeacherNIK) {
+		out = append(out, e.Previous.TeacherNIK)
+	}
+	return out
+}
+
+// ChangeMessage tells t about e. It reports false when e does not concern
+// t.
+func ChangeMessage(t models.Teacher, e events.Event) (Message, bool) {
+	s, prev := e.Schedule, e.Previous
+	if s == nil {
+		return Message{}, false
+	}
+	var subject, text string
+	switch {
+	case e.Type == events.Deleted && s.TeacherNIK == t.NIK:
+		subject, text = "Pelajaran dibatalkan", "Pelajaran berikut dibatalkan:\n\n  "+describe(s)
+	case e.Type == events.Updated && prev != nil && prev.TeacherNIK == t.NIK && s.TeacherNIK != t.NIK:
+		subject = "Pelajaran dialihkan ke guru lain"
+		text = fmt.Sprintf("Pelajaran berikut kini diajar oleh %s:\n\n  %s", s.TeacherName, describe(prev))
+	case s.TeacherNIK != t.NIK:
+		return Message{}, false
+	case s.LessonType == models.LessonSubstitute && (e.Type == events.Created || (prev != nil && prev.TeacherNIK != t.NIK)):
+		subject = "Tugas menggantikan mengajar"
+		text = "Anda ditugaskan menggantikan pada pelajaran berikut:\n\n  " + describe(s)
+		if prev != nil && prev.TeacherName != "" {
+			text += "\n\nGuru semula: " + prev.TeacherName
+		}
+	case e.Type == events.Created:
+		subject, text = "Pelajaran baru", "Pelajaran baru dijadwalkan untuk Anda:\n\n  "+describe(s)
+	case prev != nil && prev.TeacherNIK != t.NIK:
+		subject, text = "Pelajaran dialihkan kepada Anda", "Pelajaran berikut kini Anda ajar:\n\n  "+describe(s)
+	case e.Type == events.Updated:
+		subject = "Perubahan jadwal"
+		text = "Pelajaran Anda diubah.\n\n  Sekarang: " + describe(s)
+		if prev != nil {
+			text += "\n  Semula:   " + describe(prev)
+		}
+	default:
+		return Message{}, false
+	}
+	return Message{
+		To:      t.Email,
+		Subject: subject + ": " + FormatDay(s.Date),
+		Body:    fmt.Sprintf("Yth. %s,\n\n%s\n", t.Name, text),
+	}, true
+}
+
+// DigestMessage lists t's lessons of day in period order.
+func DigestMessage(t models.Teacher, day time.Time, lessons []models.Schedule) Message {
+	sorted := append([]models.Schedule(nil), lessons...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].JamKe != sorted[j].JamKe {
+			return sorted[i].JamKe < sorted[j].JamKe
+		}
+		return sorted[i].TimeStart < sorted[j].TimeStart
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "Yth. %s,\n\nJadwal mengajar Anda untuk %s:\n\n", t.Name, FormatDay(day))
+	if len(sorted) == 0 {
+		b.WriteString("  Tidak ada pelajaran.\n")
+	}
+	for _, s := range sorted {
+		class := s.ClassCode
+		if s.ClassName != "" {
+			class += " (" + s.ClassName + ")"
+		}
+		line := fmt.Sprintf("  Jam ke-%d  %s-%s  %s  %s", s.JamKe, s.TimeStart, s.TimeEnd, class, s.SubjectCode)
+		if s.LessonType == models.LessonSubstitute {
+			line += "  (menggantikan)"
+		}
+		b.WriteString(line + "\n")
+	}
+	return Message{
+		To:      t.Email,
+		Subject: fmt.Sprintf("Jadwal besok: %s (%d pelajaran)", FormatDay(day), len(sorted)),
+		Body:    b.String(),
+	}
+}
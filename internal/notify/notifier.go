@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Notifier emails teachers about changes to their lessons as they are
+// published on the event bus.
+type Notifier struct {
+	Sender Sender
+	// Teacher returns a teacher's profile, or nil when there is none.
+	// Teachers without an email address are skipped.
+	Teacher func(school, nik string) (*models.Teacher, error)
+	// Horizon limits notices to lessons starting within it, so imports
+	// and changes far ahead do not flood inboxes; the digest covers those.
+	Horizon time.Duration
+	// Now is the clock, time.Now when nil.
+	Now func() time.Time
+}
+
+// Run notifies about every event on bus until ctx ends. Events queue up
+// while mail is being sent, so a burst of changes loses no notice.
+func (n *Notifier) Run(ctx context.Context, bus *events.Bus) {
+	ch, stop := bus.Queue(events.Filter{})
+	defer stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-ch:
+			n.Handle(e)
+		}
+	}
+}
+
+// Handle sends the notices for e and returns how many were sent.
+func (n *Notifier) Handle(e events.Event) int {
+	if !n.soon(e.Schedule) && !n.soon(e.Previous) {
+		return 0
+	}
+	sent := 0
+	for _, nik := range Affected(e) {
+		t, err := n.Teacher(e.SchoolID, nik)
+		if err != nil {
+			log.Printf("notify: teacher %s: %v", nik, err)
+			continue
+		}
+		if t == nil || t.Email == "" {
+			continue
+		}
+		m, ok := ChangeMessage(*t, e)
+		if !ok {
+			continue
+		}
+		if err := n.Sender.Send(m); err != nil {
+			log.Printf("notify: mail to %s: %v", t.Email, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// soon reports whether s has not ended and starts within the horizon.
+func (n *Notifier) soon(s *models.Schedule) bool {
+	if s == nil {
+		return false
+	}
+	now := time.Now()
+	if n.Now != nil {
+		now = n.Now()
+	}
+	end := s.EndAt
+	if end.IsZero() {
+		end = s.Date.AddDate(0, 0, 1)
+	}
+	start := s.StartAt
+	if start.IsZero() {
+		start = s.Date
+	}
+	return end.After(now) && start.Before(now.Add(n.Horizon))
+}
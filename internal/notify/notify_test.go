@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+var (
+	ani  = models.Teacher{NIK: "111", Name: "Ani", Email: "ani@sekolah.id"}
+	budi = models.Teacher{NIK: "222", Name: "Budi", Email: "budi@sekolah.id"}
+	day  = time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC)
+)
+
+func lesson(nik, name string) *models.Schedule {
+	return &models.Schedule{UUID: "u1", ClassCode: "X-1", ClassName: "X IPA 1", SubjectCode: "MTK",
+		TeacherNIK: nik, TeacherName: name, Date: day, JamKe: 3, TimeStart: "08:30", TimeEnd: "09:15",
+		StartAt: day.Add(8*time.Hour + 30*time.Minute), EndAt: day.Add(9*time.Hour + 15*time.Minute)}
+}
+
+func TestFormatDay(t *testing.T) {
+	assert.Equal(t, "Selasa, 13 Agustus 2024", FormatDay(day))
+}
+
+func TestChangeMessage(t *testing.T) {
+	moved := lesson("111", "Ani")
+	moved.JamKe = 5
+	m, ok := ChangeMessage(ani, events.Event{Type: events.Updated, Schedule: moved, Previous: lesson("111", "Ani")})
+	require.True(t, ok)
+	assert.Equal(t, "ani@sekolah.id", m.To)
+	assert.Equal(t, "Perubahan jadwal: Selasa, 13 Agustus 2024", m.Subject)
+	assert.Contains(t, m.Body, "Yth. Ani")
+	assert.Contains(t, m.Body, "jam ke-5")
+	assert.Contains(t, m.Body, "jam ke-3")
+
+	sub := lesson("222", "Budi")
+	sub.LessonType = models.LessonSubstitute
+	handover := events.Event{Type: events.Updated, Schedule: sub, Previous: lesson("111", "Ani")}
+	assert.Equal(t, []string{"222", "111"}, Affected(handover))
+	m, ok = ChangeMessage(budi, handover)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(m.Subject, "Tugas menggantikan mengajar"))
+	assert.Contains(t, m.Body, "Guru semula: Ani")
+	m, ok = ChangeMessage(ani, handover)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(m.Subject, "Pelajaran dialihkan ke guru lain"))
+	assert.Contains(t, m.Body, "Budi")
+
+	m, ok = ChangeMessage(ani, events.Event{Type: events.Deleted, Schedule: lesson("111", "Ani")})
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(m.Subject, "Pelajaran dibatalkan"))
+
+	_, ok = ChangeMessage(budi, events.Event{Type: events.Created, Schedule: lesson("111", "Ani")})
+	assert.False(t, ok)
+}
+
+func TestDigestMessage(t *testing.T) {
+	first, second := lesson("111", "Ani"), lesson("111", "Ani")
+	first.JamKe, first.TimeStart, first.TimeEnd = 1, "07:00", "07:45"
+	second.LessonType = models.LessonSubstitute
+	m := DigestMessage(ani, day, []models.Schedule{*second, *first})
+	assert.Equal(t, "Jadwal besok: Selasa, 13 Agustus 2024 (2 pelajaran)", m.Subject)
+	assert.Less(t, strings.Index(m.Body, "Jam ke-1"), strings.Index(m.Body, "Jam ke-3"))
+	assert.Contains(t, m.Body, "(menggantikan)")
+}
+
+type sent []Message
+
+func (s *sent) Send(m Message) error {
+	*s = append(*s, m)
+	return nil
+}
+
+func TestNotifierHandle(t *testing.T) {
+	var out sent
+	teachers := map[string]*models.Teacher{"111": &ani, "222": {NIK: "222", Name: "Budi"}}
+	n := &Notifier{
+		Sender:  &out,
+		Teacher: func(school, nik string) (*models.Teacher, error) { return teachers[nik], nil },
+		Horizon: 3 * 24 * time.Hour,
+		Now:     func() time.Time { return day.Add(-24 * time.Hour) },
+	}
+	e := events.Event{Type: events.Updated, SchoolID: "a", Schedule: lesson("222", "Budi"), Previous: lesson("111", "Ani")}
+	assert.Equal(t, 1, n.Handle(e), "Budi has no email")
+	require.Len(t, out, 1)
+	assert.Equal(t, "ani@sekolah.id", out[0].To)
+
+	n.Now = func() time.Time { return day.AddDate(0, 0, -10) }
+	assert.Zero(t, n.Handle(e), "too far ahead")
+	n.Now = func() time.Time { return day.AddDate(0, 0, 1) }
+	assert.Zero(t, n.Handle(e), "already over")
+}
+
+func TestLogSender(t *testing.T) {
+	dir := t.TempDir()
+	s := &LogSender{Dir: dir, From: "jadwal@sekolah.id"}
+	require.NoError(t, s.Send(Message{To: "ani@sekolah.id", Subject: "Jadwal besok", Body: "baris 1\nbaris 2"}))
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	raw, err := os.ReadFile(dir + "/" + files[0].Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "To: ani@sekolah.id\r\n")
+	assert.Contains(t, string(raw), "From: jadwal@sekolah.id\r\n")
+	assert.Contains(t, string(raw), "baris 1\r\nbaris 2")
+}
@@ -0,0 +1,95 @@
+// Package notify tells teachers about changes to their lessons and sends
+// the daily digest of tomorrow's lessons. Messages go out through a
+// Sender: SMTP in production, files or the log during development.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/config"
+)
+
+// Message is one plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Bytes renders m as an RFC 5322 message from from.
+func (m Message) Bytes(from string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(m Message) error
+}
+
+// SMTPSender sends through an SMTP server, authenticating when Username
+// is set.
+type SMTPSender struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (s SMTPSender) Send(m Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	return smtp.SendMail(s.Addr, auth, s.From, []string{m.To}, m.Bytes(s.From))
+}
+
+// LogSender is for local development: it writes each message as an .eml
+// file into Dir, or to the log when Dir is empty.
+type LogSender struct {
+	Dir  string
+	From string
+	seq  atomic.Int64
+}
+
+func (s *LogSender) Send(m Message) error {
+	if s.Dir == "" {
+		log.Printf("mail to %s: %s\n%s", m.To, m.Subject, m.Body)
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().Format("20060102-150405"), s.seq.Add(1))
+	return os.WriteFile(filepath.Join(s.Dir, name), m.Bytes(s.From), 0o644)
+}
+
+// NewSender returns an SMTPSender when NOTIFY_SMTP_ADDR is configured and
+// a LogSender otherwise.
+func NewSender(cfg config.Config) Sender {
+	if cfg.NotifySMTPAddr != "" {
+		return SMTPSender{Addr: cfg.NotifySMTPAddr, From: cfg.NotifyFrom, Username: cfg.NotifySMTPUser, Password: cfg.NotifySMTPPassword}
+	}
+	return &LogSender{Dir: cfg.NotifyDir, From: cfg.NotifyFrom}
+}
@@ -0,0 +1,304 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Content types selecting the patch format.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Merge applies the merge patch p to doc. Members set to null in p are
+// removed, objects are merged recursively and every other value replaces
+// the target member as a whole.
+func Merge(doc, p []byte) ([]byte, error) {
+	var target, patch interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	if err := json.Unmarshal(p, &patch); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return json.Marshal(merge(target, patch))
+}
+
+func merge(target, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]interface{})
+	if !ok {
+		tm = map[string]interface{}{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = merge(tm[k], v)
+	}
+	return tm
+}
+
+// Operation is one step of a JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies the JSON Patch p to doc. Operations run in order and the
+// whole patch fails if any of them does.
+func Apply(doc, p []byte) ([]byte, error) {
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	var ops []Operation
+	if err := json.Unmarshal(p, &ops); err != nil {
+		return nil, fmt.Errorf("invalid json patch: %w", err)
+	}
+
+	for i, op := range ops {
+		var err error
+		root, err = apply(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func apply(root interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (interface{}, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("value required")
+		}
+		var v interface{}
+		err := json.Unmarshal(op.Value, &v)
+		return v, err
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, v)
+	case "remove":
+		root, _, err := remove(root, path)
+		return root, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if root, _, err = remove(root, path); err != nil {
+			return nil, err
+		}
+		return add(root, path, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			if root, _, err = remove(root, from); err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopy(v)
+		}
+		return add(root, path, v)
+	case "test":
+		want, err := value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(got, want) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid pointer %q", p)
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, s := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+	}
+	return parts, nil
+}
+
+func index(tok string, n int, allowEnd bool) (int, error) {
+	if allowEnd && tok == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || (tok != "0" && strings.HasPrefix(tok, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	max := n - 1
+	if allowEnd {
+		max = n
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, tok := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			node = v
+		case []interface{}:
+			i, err := index(tok, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q", tok)
+		}
+	}
+	return node, nil
+}
+
+// add inserts v at path and returns the (possibly new) root.
+func add(root interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[last] = v
+		return root, nil
+	case []interface{}:
+		i, err := index(last, len(p), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := append(p[:i:i], append([]interface{}{v}, p[i:]...)...)
+		return set(root, path[:len(path)-1], grown)
+	}
+	return nil, fmt.Errorf("cannot add to %q", last)
+}
+
+// remove deletes the value at path and returns the new root and the
+// removed value.
+func remove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, root, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		v, ok := p[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", last)
+		}
+		delete(p, last)
+		return root, v, nil
+	case []interface{}:
+		i, err := index(last, len(p), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := p[i]
+		shrunk := append(p[:i:i], p[i+1:]...)
+		root, err = set(root, path[:len(path)-1], shrunk)
+		return root, v, err
+	}
+	return nil, nil, fmt.Errorf("cannot remove from %q", last)
+}
+
+// set replaces the value at an existing path; arrays change length on add
+// and remove, so their parent has to be updated.
+func set(root interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[last] = v
+	case []interface{}:
+		i, err := index(last, len(p), false)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+	return root, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, x := range t {
+			m[k] = deepCopy(x)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, x := range t {
+			s[i] = deepCopy(x)
+		}
+		return s
+	}
+	return v
+}
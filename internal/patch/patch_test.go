@@ -0,0 +1,76 @@
+package patch_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/patch"
+)
+
+func TestMerge(t *testing.T) {
+	// Examples from RFC 7396, appendix A.
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		got, err := patch.Merge([]byte(tc.doc), []byte(tc.patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, tc.want, string(got), "%s + %s", tc.doc, tc.patch)
+	}
+
+	_, err := patch.Merge([]byte(`{}`), []byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"jam_ke":1,"tags":["a","b"],"x/y":{"z":true}}`
+	cases := []struct{ ops, want string }{
+		{`[{"op":"replace","path":"/jam_ke","value":3}]`, `{"jam_ke":3,"tags":["a","b"],"x/y":{"z":true}}`},
+		{`[{"op":"remove","path":"/tags/0"}]`, `{"jam_ke":1,"tags":["b"],"x/y":{"z":true}}`},
+		{`[{"op":"add","path":"/tags/-","value":"c"}]`, `{"jam_ke":1,"tags":["a","b","c"],"x/y":{"z":true}}`},
+		{`[{"op":"add","path":"/tags/1","value":"c"}]`, `{"jam_ke":1,"tags":["a","c","b"],"x/y":{"z":true}}`},
+		{`[{"op":"remove","path":"/x~1y/z"}]`, `{"jam_ke":1,"tags":["a","b"],"x/y":{}}`},
+		{`[{"op":"move","from":"/jam_ke","path":"/period"}]`, `{"period":1,"tags":["a","b"],"x/y":{"z":true}}`},
+		{`[{"op":"copy","from":"/tags","path":"/copy"}]`, `{"jam_ke":1,"tags":["a","b"],"copy":["a","b"],"x/y":{"z":true}}`},
+		{`[{"op":"test","path":"/jam_ke","value":1},{"op":"add","path":"/n","value":null}]`, `{"jam_ke":1,"n":null,"tags":["a","b"],"x/y":{"z":true}}`},
+	}
+	for _, tc := range cases {
+		got, err := patch.Apply([]byte(doc), []byte(tc.ops))
+		require.NoError(t, err, tc.ops)
+		assert.JSONEq(t, tc.want, string(got), tc.ops)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := []byte(`{"jam_ke":1,"tags":["a"]}`)
+	for _, ops := range []string{
+		`[{"op":"test","path":"/jam_ke","value":2}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"add","path":"/tags/5","value":1}]`,
+		`[{"op":"add","path":"jam_ke","value":1}]`,
+		`[{"op":"replace","path":"/jam_ke"}]`,
+		`[{"op":"move","from":"/tags","path":"/tags/0"}]`,
+		`[{"op":"frobnicate","path":"/jam_ke"}]`,
+		`{"op":"remove"}`,
+	} {
+		_, err := patch.Apply(doc, []byte(ops))
+		assert.Error(t, err, ops)
+	}
+
+	// A failing operation leaves nothing half-applied for the caller.
+	got, err := patch.Apply(doc, []byte(`[{"op":"replace","path":"/jam_ke","value":9},{"op":"test","path":"/jam_ke","value":1}]`))
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,128 @@
+package payroll
+
+import (
+	"sort"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Line is the honorarium of one teacher for a period.
+type Line struct {
+	TeacherNIK     string  `json:"teacher_nik"`
+	TeacherName    string  `json:"teacher_name"`
+	EmploymentType string  `json:"employment_type"`
+	RegularJP      float64 `json:"regular_jp"`
+	ExtraJP        float64 `json:"extra_jp"`
+	SubstituteJP   float64 `json:"substitute_jp"`
+	RegularRate    float64 `json:"regular_rate"`
+	ExtraRate      float64 `json:"extra_rate"`
+	SubstituteRate float64 `json:"substitute_rate"`
+	Amount         float64 `json:"amount"`
+	RateMissing    bool    `json:"rate_missing"`
+}
+
+// Summary is the honorarium of every teacher with delivered lessons.
+type Summary struct {
+	Lines        []Line  `json:"lines"`
+	TotalJP      float64 `json:"total_jp"`
+	TotalAmount  float64 `json:"total_amount"`
+	MissingRates int     `json:"missing_rates"`
+}
+
+// Rates resolves the applicable rate of a teacher: a teacher-specific rate
+// wins over the rate of the teacher's employment type.
+type Rates struct {
+	byTeacher map[string]models.HonorariumRate
+	byType    map[string]models.HonorariumRate
+}
+
+func NewRates(rates []models.HonorariumRate) Rates {
+	r := Rates{byTeacher: map[string]models.HonorariumRate{}, byType: map[string]models.HonorariumRate{}}
+	for _, rt := range rates {
+		if rt.TeacherNIK != "" {
+			r.byTeacher[rt.TeacherNIK] = rt
+		} else if rt.EmploymentType != "" {
+			r.byType[rt.EmploymentType] = rt
+		}
+	}
+	return r
+}
+
+func (r Rates) For(nik, employmentType string) (models.HonorariumRate, bool) {
+	if rt, ok := r.byTeacher[nik]; ok {
+		return rt, true
+	}
+	if employmentType == "" {
+		return models.HonorariumRate{}, false
+	}
+	rt, ok := r.byType[employmentType]
+	return rt, ok
+}
+
+// Compute builds the honorarium summary of rows. teachers maps NIK to the
+// teacher profile and is used for the employment type. Only lessons the
+// journal records as taught are paid: a substituted lesson is paid to the
+// substitute at the substitute rate, a cancelled or unrecorded one not at
+// all.
+func Compute(rows []models.Schedule, calc jp.Calculator, teachers map[string]models.Teacher, rates Rates, journal models.Journal) Summary {
+	lines := map[string]*Line{}
+	for _, s := range rows {
+		if !journal.Delivered(s) {
+			continue
+		}
+		nik, name := journal.TaughtBy(s)
+		l, ok := lines[nik]
+		if !ok {
+			l = &Line{TeacherNIK: nik, TeacherName: name}
+			if t, ok := teachers[nik]; ok {
+				l.EmploymentType = t.EmploymentType
+				if t.Name != "" {
+					l.TeacherName = t.Name
+				}
+			}
+			lines[nik] = l
+		}
+		lessonJP := calc.Of(s)
+		switch {
+		case journal.Status(s) == models.JournalSubstituted:
+			l.SubstituteJP += lessonJP
+		case s.LessonType == models.LessonExtra:
+			l.ExtraJP += lessonJP
+		case s.LessonType == models.LessonSubstitute:
+			l.SubstituteJP += lessonJP
+		default:
+			l.RegularJP += lessonJP
+		}
+	}
+
+	var sum Summary
+	for _, l := range lines {
+		rt, ok := rates.For(l.TeacherNIK, l.EmploymentType)
+		l.RateMissing = !ok
+		l.RegularRate = rt.RegularRate
+		l.ExtraRate = orRegular(rt.ExtraRate, rt.RegularRate)
+		l.SubstituteRate = orRegular(rt.SubstituteRate, rt.RegularRate)
+		l.Amount = l.RegularJP*l.RegularRate + l.ExtraJP*l.ExtraRate + l.SubstituteJP*l.SubstituteRate
+
+		sum.Lines = append(sum.Lines, *l)
+		sum.TotalJP += l.RegularJP + l.ExtraJP + l.SubstituteJP
+		sum.TotalAmount += l.Amount
+		if l.RateMissing {
+			sum.MissingRates++
+		}
+	}
+	sort.Slice(sum.Lines, func(i, j int) bool { return sum.Lines[i].TeacherNIK < sum.Lines[j].TeacherNIK })
+	if sum.Lines == nil {
+		sum.Lines = []Line{}
+	}
+	return sum
+}
+
+// orRegular returns rate, or regular when rate is not set.
+func orRegular(rate *float64, regular float64) float64 {
+	if rate == nil {
+		return regular
+	}
+	return *rate
+}
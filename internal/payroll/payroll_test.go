@@ -0,0 +1,89 @@
+package payroll_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/payroll"
+)
+
+func TestCompute(t *testing.T) {
+	calc := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	d := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+	rate := func(v float64) *float64 { return &v }
+
+	rows := []models.Schedule{
+		{UUID: "a", TeacherNIK: "1", TeacherName: "Budi", Date: d(1), TimeStart: "07:00:00", TimeEnd: "08:30:00"},
+		{UUID: "b", TeacherNIK: "1", TeacherName: "Budi", Date: d(2), TimeStart: "07:00:00", TimeEnd: "07:45:00", LessonType: models.LessonExtra},
+		{UUID: "c", TeacherNIK: "1", TeacherName: "Budi", Date: d(20), TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{UUID: "d", TeacherNIK: "1", TeacherName: "Budi", Date: d(4), TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{UUID: "e", TeacherNIK: "1", TeacherName: "Budi", Date: d(5), TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{UUID: "f", TeacherNIK: "2", TeacherName: "Sari", Date: d(3), TimeStart: "07:00:00", TimeEnd: "07:45:00", LessonType: models.LessonSubstitute},
+		{UUID: "g", TeacherNIK: "3", TeacherName: "Joko", Date: d(3), TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+	}
+	// c is not recorded yet, d was taken by Sari and e was cancelled.
+	journal := models.NewJournal([]models.JournalEntry{
+		{ScheduleUUID: "a", Status: models.JournalDelivered},
+		{ScheduleUUID: "b", Status: models.JournalDelivered},
+		{ScheduleUUID: "d", Status: models.JournalSubstituted, SubstituteNIK: "2", SubstituteName: "Sari"},
+		{ScheduleUUID: "e", Status: models.JournalCancelled},
+		{ScheduleUUID: "f", Status: models.JournalDelivered},
+		{ScheduleUUID: "g", Status: models.JournalDelivered},
+	})
+	teachers := map[string]models.Teacher{
+		"1": {NIK: "1", EmploymentType: "GTT"},
+		"2": {NIK: "2", EmploymentType: "GTT"},
+	}
+	rates := payroll.NewRates([]models.HonorariumRate{
+		{EmploymentType: "GTT", RegularRate: 50000, ExtraRate: rate(60000)},
+		{TeacherNIK: "2", RegularRate: 70000, SubstituteRate: rate(40000)},
+	})
+
+	sum := payroll.Compute(rows, calc, teachers, rates, journal)
+
+	assert.Len(t, sum.Lines, 3)
+	budi := sum.Lines[0]
+	assert.Equal(t, 2.0, budi.RegularJP)
+	assert.Equal(t, 1.0, budi.ExtraJP)
+	assert.Equal(t, 2*50000.0+60000, budi.Amount)
+
+	sari := sum.Lines[1]
+	assert.Equal(t, 2.0, sari.SubstituteJP)
+	assert.Equal(t, 2*40000.0, sari.Amount)
+
+	joko := sum.Lines[2]
+	assert.True(t, joko.RateMissing)
+	assert.Equal(t, 0.0, joko.Amount)
+
+	assert.Equal(t, 6.0, sum.TotalJP)
+	assert.Equal(t, 240000.0, sum.TotalAmount)
+	assert.Equal(t, 1, sum.MissingRates)
+}
+
+func TestComputeUnsetRates(t *testing.T) {
+	calc := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	zero := 0.0
+	rows := []models.Schedule{
+		{UUID: "a", TeacherNIK: "1", Date: day, TimeStart: "07:00:00", TimeEnd: "07:45:00", LessonType: models.LessonExtra},
+		{UUID: "b", TeacherNIK: "2", Date: day, TimeStart: "07:00:00", TimeEnd: "07:45:00", LessonType: models.LessonExtra},
+	}
+	journal := models.NewJournal([]models.JournalEntry{
+		{ScheduleUUID: "a", Status: models.JournalDelivered},
+		{ScheduleUUID: "b", Status: models.JournalDelivered},
+	})
+	rates := payroll.NewRates([]models.HonorariumRate{
+		{TeacherNIK: "1", RegularRate: 50000},
+		{TeacherNIK: "2", RegularRate: 50000, ExtraRate: &zero},
+	})
+
+	sum := payroll.Compute(rows, calc, nil, rates, journal)
+	assert.Equal(t, 50000.0, sum.Lines[0].ExtraRate)
+	assert.Equal(t, 50000.0, sum.Lines[0].Amount)
+	assert.Equal(t, 0.0, sum.Lines[1].ExtraRate)
+	assert.Equal(t, 0.0, sum.Lines[1].Amount)
+}
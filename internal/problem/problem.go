@@ -0,0 +1,64 @@
+// Package problem writes error responses as RFC 7807 problem details
+// (application/problem+json). Every problem carries a stable code that
+// clients can switch on and the ID of the request for log correlation.
+package problem
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType of problem responses.
+const ContentType = "application/problem+json"
+
+// RequestIDKey is the gin context key holding the request ID.
+const RequestIDKey = "request_id"
+
+// Stable error codes.
+const (
+	CodeBadRequest        = "bad_request"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeScheduleConflict  = "schedule_conflict"
+	CodeWorkloadExceeded  = "workload_limit_exceeded"
+	CodeStaleConfirmation = "stale_confirmation"
+	CodeTermLocked        = "term_locked"
+	CodeDraftOpen         = "draft_open"
+	CodeValidation        = "validation_failed"
+	CodeUnsupportedMedia  = "unsupported_media_type"
+	CodeNoTransactions    = "transactions_unavailable"
+	CodeInternal          = "internal_error"
+)
+
+// typeBase prefixes the code to form the problem type URI.
+const typeBase = "urn:egs:problem:"
+
+// Write aborts the request with a problem. ext adds extension members such
+// as the list of invalid fields.
+func Write(c *gin.Context, status int, code, detail string, ext gin.H) {
+	body := gin.H{
+		"type":   typeBase + code,
+		"title":  http.StatusText(status),
+		"status": status,
+		"code":   code,
+	}
+	if detail != "" {
+		body["detail"] = detail
+	}
+	if c.Request != nil && c.Request.URL != nil {
+		body["instance"] = c.Request.URL.Path
+	}
+	if id := c.GetString(RequestIDKey); id != "" {
+		body["request_id"] = id
+	}
+	for k, v := range ext {
+		if _, taken := body[k]; !taken {
+			body[k] = v
+		}
+	}
+	c.Abort()
+	c.Render(status, jsonRender{body})
+}
@@ -0,0 +1,44 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/problem"
+)
+
+func TestWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/schedules/abc", nil)
+	c.Set(problem.RequestIDKey, "req-1")
+
+	problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "schedule abc not found", gin.H{
+		"status": 500, // extensions cannot override the standard members
+		"uuid":   "abc",
+	})
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]interface{}{
+		"type":       "urn:egs:problem:not_found",
+		"title":      "Not Found",
+		"status":     float64(404),
+		"code":       "not_found",
+		"detail":     "schedule abc not found",
+		"instance":   "/api/schedules/abc",
+		"request_id": "req-1",
+		"uuid":       "abc",
+	}, body)
+}
@@ -0,0 +1,25 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// jsonRender is gin's JSON renderer with the problem content type.
+type jsonRender struct {
+	Data interface{}
+}
+
+func (r jsonRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	b, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (r jsonRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+}
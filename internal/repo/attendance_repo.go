@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttendanceRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewAttendanceRepo(coll *mongo.Collection) *AttendanceRepo {
+	ctx := context.Background()
+
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "schedule_uuid", Value: 1}, {Key: "student_nis", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "class_code", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "student_nis", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+	})
+
+	return &AttendanceRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *AttendanceRepo) For(school string) *AttendanceRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Save records the attendance of a lesson, replacing what was recorded
+// before for the same students.
+func (r *AttendanceRepo) Save(records []models.Attendance) error {
+	if len(records) == 0 {
+		return nil
+	}
+	now := time.Now()
+	writes := make([]mongo.WriteModel, len(records))
+	for i := range records {
+		a := &records[i]
+		a.SchoolID = r.School
+		a.RecordedAt = now
+		writes[i] = mongo.NewReplaceOneModel().
+			SetFilter(scoped(r.School, bson.M{"schedule_uuid": a.ScheduleUUID, "student_nis": a.StudentNIS})).
+			SetReplacement(a).
+			SetUpsert(true)
+	}
+	_, err := r.Coll.BulkWrite(r.Ctx, writes)
+	return translate(err)
+}
+
+// Find lists records matching filter by day and student.
+func (r *AttendanceRepo) Find(filter bson.M) ([]models.Attendance, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(r.School, filter),
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "student_nis", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Attendance{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
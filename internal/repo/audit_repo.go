@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewAuditRepo(coll *mongo.Collection) *AuditRepo {
+	ctx := context.Background()
+
+	dropIndex(ctx, coll, "created_at_-1")
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &AuditRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *AuditRepo) For(school string) *AuditRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *AuditRepo) Insert(e *models.AuditEntry) error {
+	e.SchoolID = r.School
+	e.CreatedAt = time.Now()
+	res, err := r.Coll.InsertOne(r.Ctx, e)
+	if err != nil {
+		return err
+	}
+	e.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Find lists the newest entries first, optionally of one action.
+func (r *AuditRepo) Find(action string, limit int64) ([]models.AuditEntry, error) {
+	filter := scoped(r.School, nil)
+	if action != "" {
+		filter["action"] = action
+	}
+	cur, err := r.Coll.Find(r.Ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.AuditEntry{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AvailabilityRepo struct {
+	Windows *mongo.Collection
+	Leaves  *mongo.Collection
+	Ctx     context.Context
+	School  string
+}
+
+func NewAvailabilityRepo(windows, leaves *mongo.Collection) *AvailabilityRepo {
+	ctx := context.Background()
+
+	dropIndex(ctx, windows, "teacher_nik_1_weekday_1")
+	dropIndex(ctx, leaves, "teacher_nik_1_start_date_1")
+	_, _ = windows.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "teacher_nik", Value: 1}, {Key: "weekday", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+	_, _ = leaves.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "teacher_nik", Value: 1}, {Key: "start_date", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &AvailabilityRepo{Windows: windows, Leaves: leaves, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *AvailabilityRepo) For(school string) *AvailabilityRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *AvailabilityRepo) InsertWindow(w *models.AvailabilityWindow) error {
+	w.SchoolID = r.School
+	w.CreatedAt = time.Now()
+	res, err := r.Windows.InsertOne(r.Ctx, w)
+	if err != nil {
+		return err
+	}
+	w.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *AvailabilityRepo) InsertLeave(l *models.Unavailability) error {
+	l.SchoolID = r.School
+	l.CreatedAt = time.Now()
+	res, err := r.Leaves.InsertOne(r.Ctx, l)
+	if err != nil {
+		return err
+	}
+	l.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *AvailabilityRepo) WindowsOf(nik string) ([]models.AvailabilityWindow, error) {
+	cur, err := r.Windows.Find(r.Ctx, scoped(r.School, bson.M{"teacher_nik": nik}),
+		options.Find().SetSort(bson.D{{Key: "weekday", Value: 1}, {Key: "jam_ke_from", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.AvailabilityWindow{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LeavesOf returns the unavailability of nik overlapping [from, to]. Zero
+// times leave that side of the range open.
+func (r *AvailabilityRepo) LeavesOf(nik string, from, to time.Time) ([]models.Unavailability, error) {
+	filter := scoped(r.School, bson.M{"teacher_nik": nik})
+	if !from.IsZero() {
+		filter["end_date"] = bson.M{"$gte": from}
+	}
+	if !to.IsZero() {
+		filter["start_date"] = bson.M{"$lte": to}
+	}
+	cur, err := r.Leaves.Find(r.Ctx, filter, options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Unavailability{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func deleteOwned(ctx context.Context, coll *mongo.Collection, school, nik string, id primitive.ObjectID) error {
+	res, err := coll.DeleteOne(ctx, scoped(school, bson.M{"_id": id, "teacher_nik": nik}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *AvailabilityRepo) DeleteWindow(nik string, id primitive.ObjectID) error {
+	return deleteOwned(r.Ctx, r.Windows, r.School, nik, id)
+}
+
+func (r *AvailabilityRepo) DeleteLeave(nik string, id primitive.ObjectID) error {
+	return deleteOwned(r.Ctx, r.Leaves, r.School, nik, id)
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BellRepo stores the bell schedules, one document per period and school.
+type BellRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewBellRepo(coll *mongo.Collection) *BellRepo {
+	ctx := context.Background()
+
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "jam_ke", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &BellRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *BellRepo) For(school string) *BellRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *BellRepo) Get() ([]models.BellPeriod, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(r.School, nil), options.Find().SetSort(bson.D{{Key: "jam_ke", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.BellPeriod{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Replace swaps the school's whole bell schedule for periods. The new
+// periods are stored before the old ones are removed, so a failure leaves
+// the old schedule in place rather than none.
+func (r *BellRepo) Replace(periods []models.BellPeriod) error {
+	keep := bson.A{}
+	if len(periods) > 0 {
+		docs := make([]interface{}, len(periods))
+		for i, p := range periods {
+			p.SchoolID = r.School
+			docs[i] = p
+		}
+		res, err := r.Coll.InsertMany(r.Ctx, docs)
+		if err != nil {
+			if res != nil {
+				_, _ = r.Coll.DeleteMany(r.Ctx, bson.M{"_id": bson.M{"$in": res.InsertedIDs}})
+			}
+			return err
+		}
+		keep = append(keep, res.InsertedIDs...)
+	}
+	_, err := r.Coll.DeleteMany(r.Ctx, scoped(r.School, bson.M{"_id": bson.M{"$nin": keep}}))
+	return err
+}
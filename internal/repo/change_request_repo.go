@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ChangeRequestRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewChangeRequestRepo(coll *mongo.Collection) *ChangeRequestRepo {
+	ctx := context.Background()
+
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &ChangeRequestRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *ChangeRequestRepo) For(school string) *ChangeRequestRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *ChangeRequestRepo) Insert(cr *models.ChangeRequest) error {
+	cr.SchoolID = r.School
+	cr.Status = models.RequestPending
+	cr.CreatedAt = time.Now()
+	res, err := r.Coll.InsertOne(r.Ctx, cr)
+	if err != nil {
+		return err
+	}
+	cr.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Find lists requests, newest first, optionally of one status and teacher.
+func (r *ChangeRequestRepo) Find(status, teacherNIK string) ([]models.ChangeRequest, error) {
+	filter := scoped(r.School, nil)
+	if status != "" {
+		filter["status"] = status
+	}
+	if teacherNIK != "" {
+		filter["teacher_nik"] = teacherNIK
+	}
+	cur, err := r.Coll.Find(r.Ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.ChangeRequest{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *ChangeRequestRepo) Get(id primitive.ObjectID) (*models.ChangeRequest, error) {
+	var cr models.ChangeRequest
+	if err := r.Coll.FindOne(r.Ctx, scoped(r.School, bson.M{"_id": id})).Decode(&cr); err != nil {
+		return nil, translate(err)
+	}
+	return &cr, nil
+}
+
+// Review moves a pending request to status. It fails with ErrConflict when
+// the request has already been reviewed.
+func (r *ChangeRequestRepo) Review(cr *models.ChangeRequest, status, note string) error {
+	now := time.Now()
+	res, err := r.Coll.UpdateOne(r.Ctx,
+		scoped(r.School, bson.M{"_id": cr.ID, "status": models.RequestPending}),
+		bson.M{"$set": bson.M{"status": status, "review_note": note, "reviewed_at": now}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrConflict
+	}
+	cr.Status, cr.ReviewNote, cr.ReviewedAt = status, note, &now
+	return nil
+}
+
+// Reopen returns an approved request to pending, undoing Review when the
+// approved change could not be applied.
+func (r *ChangeRequestRepo) Reopen(cr *models.ChangeRequest) error {
+	_, err := r.Coll.UpdateOne(r.Ctx,
+		scoped(r.School, bson.M{"_id": cr.ID, "status": models.RequestApproved}),
+		bson.M{"$set": bson.M{"status": models.RequestPending}, "$unset": bson.M{"review_note": "", "reviewed_at": ""}})
+	if err != nil {
+		return err
+	}
+	cr.Status, cr.ReviewNote, cr.ReviewedAt = models.RequestPending, "", nil
+	return nil
+}
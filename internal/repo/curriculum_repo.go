@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CurriculumRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewCurriculumRepo(coll *mongo.Collection) *CurriculumRepo {
+	ctx := context.Background()
+
+	dropIndex(ctx, coll, "class_code_1_subject_code_1")
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "school_id", Value: 1},
+			{Key: "class_code", Value: 1},
+			{Key: "subject_code", Value: 1},
+		},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	})
+
+	return &CurriculumRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *CurriculumRepo) For(school string) *CurriculumRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Upsert stores the requirement of req's class and subject.
+func (r *CurriculumRepo) Upsert(req *models.CurriculumRequirement) error {
+	req.SchoolID = r.School
+	req.UpdatedAt = time.Now()
+	filter := scoped(r.School, bson.M{"class_code": req.ClassCode, "subject_code": req.SubjectCode})
+	_, err := r.Coll.ReplaceOne(r.Ctx, filter, req, options.Replace().SetUpsert(true))
+	return translate(err)
+}
+
+// Find lists requirements, optionally of one class.
+func (r *CurriculumRepo) Find(classCode string) ([]models.CurriculumRequirement, error) {
+	filter := scoped(r.School, nil)
+	if classCode != "" {
+		filter["class_code"] = classCode
+	}
+	cur, err := r.Coll.Find(r.Ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "class_code", Value: 1}, {Key: "subject_code", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.CurriculumRequirement{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *CurriculumRepo) Delete(id primitive.ObjectID) error {
+	res, err := r.Coll.DeleteOne(r.Ctx, scoped(r.School, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DigestRepo records which daily digests went out, and to whom, so each
+// teacher gets one per day however many servers run or restart, and a
+// failed run is retried for the teachers it missed.
+type DigestRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewDigestRepo(coll *mongo.Collection) *DigestRepo {
+	ctx := context.Background()
+
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return &DigestRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *DigestRepo) For(school string) *DigestRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Claim takes the digest of day for sending for lease and returns the
+// teachers it already went to on an earlier, failed run. ok is false when
+// the digest is done or another server is sending it.
+func (r *DigestRepo) Claim(day time.Time, lease time.Duration) (sent []string, ok bool, err error) {
+	now := time.Now()
+	filter := scoped(r.School, bson.M{
+		"date":    day,
+		"sent_at": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"claimed_until": bson.M{"$exists": false}},
+			{"claimed_until": bson.M{"$lt": now}},
+		},
+	})
+	var run struct {
+		Sent []string `bson:"sent"`
+	}
+	err = r.Coll.FindOneAndUpdate(r.Ctx, filter,
+		bson.M{"$set": bson.M{"claimed_until": now.Add(lease)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&run)
+	if errors.Is(translate(err), ErrConflict) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return run.Sent, true, nil
+}
+
+// Finish releases the claim on the digest of day and records the
+// teachers it went to. Unless done, the next Claim retries the rest.
+func (r *DigestRepo) Finish(day time.Time, sent []string, done bool) error {
+	if sent == nil {
+		sent = []string{}
+	}
+	update := bson.M{
+		"$unset":    bson.M{"claimed_until": ""},
+		"$addToSet": bson.M{"sent": bson.M{"$each": sent}},
+	}
+	if done {
+		update["$set"] = bson.M{"sent_at": time.Now()}
+	}
+	_, err := r.Coll.UpdateOne(r.Ctx, scoped(r.School, bson.M{"date": day}), update)
+	return err
+}
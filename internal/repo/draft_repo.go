@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DraftRepo stores drafts and, in a separate collection, the lessons they
+// hold, so that no published query ever sees a draft lesson.
+type DraftRepo struct {
+	Coll    *mongo.Collection
+	Lessons *mongo.Collection
+	Ctx     context.Context
+	School  string
+}
+
+func NewDraftRepo(drafts, lessons *mongo.Collection) *DraftRepo {
+	ctx := context.Background()
+
+	_, _ = drafts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "status", Value: 1}, {Key: "start_date", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+	_, _ = lessons.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "draft_id", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &DraftRepo{Coll: drafts, Lessons: lessons, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *DraftRepo) For(school string) *DraftRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Open stores d as an open draft holding copies of lessons.
+func (r *DraftRepo) Open(d *models.Draft, lessons []models.Schedule) error {
+	d.SchoolID = r.School
+	d.Status = models.DraftOpen
+	d.CreatedAt = time.Now()
+	res, err := r.Coll.InsertOne(r.Ctx, d)
+	if err != nil {
+		return translate(err)
+	}
+	d.ID = res.InsertedID.(primitive.ObjectID)
+	if len(lessons) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(lessons))
+	for i, s := range lessons {
+		s.ID = primitive.NilObjectID
+		s.SchoolID = r.School
+		s.DraftID = &d.ID
+		docs[i] = s
+	}
+	_, err = r.Lessons.InsertMany(r.Ctx, docs)
+	return err
+}
+
+// Find lists drafts, newest first, optionally of one status.
+func (r *DraftRepo) Find(status string) ([]models.Draft, error) {
+	filter := scoped(r.School, nil)
+	if status != "" {
+		filter["status"] = status
+	}
+	cur, err := r.Coll.Find(r.Ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Draft{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *DraftRepo) Get(id primitive.ObjectID) (*models.Draft, error) {
+	var d models.Draft
+	if err := r.Coll.FindOne(r.Ctx, scoped(r.School, bson.M{"_id": id})).Decode(&d); err != nil {
+		return nil, translate(err)
+	}
+	return &d, nil
+}
+
+// LessonsOf returns the lessons held by draft id.
+func (r *DraftRepo) LessonsOf(id primitive.ObjectID) ([]models.Schedule, error) {
+	cur, err := r.Lessons.Find(r.Ctx, scoped(r.School, bson.M{"draft_id": id}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Schedule{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Discard closes an open draft without publishing it.
+func (r *DraftRepo) Discard(id primitive.ObjectID) error {
+	res, err := r.Coll.UpdateOne(r.Ctx,
+		scoped(r.School, bson.M{"_id": id, "status": models.DraftOpen}),
+		bson.M{"$set": bson.M{"status": models.DraftDiscarded}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	_, err = r.Lessons.DeleteMany(r.Ctx, scoped(r.School, bson.M{"draft_id": id}))
+	return err
+}
+
+// Publish replaces the published lessons dated within the draft's range by
+// lessons in one transaction and closes the draft. It fails with
+// ErrConflict when the draft is no longer open.
+func (r *DraftRepo) Publish(d *models.Draft, published *mongo.Collection, lessons []models.Schedule) error {
+	now := time.Now()
+	err := withTransaction(r.Ctx, r.Coll.Database().Client(), func(sc mongo.SessionContext) (interface{}, error) {
+		res, err := r.Coll.UpdateOne(sc,
+			scoped(r.School, bson.M{"_id": d.ID, "status": models.DraftOpen}),
+			bson.M{"$set": bson.M{"status": models.DraftPublished, "published_at": now}})
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, ErrConflict
+		}
+		if _, err := published.DeleteMany(sc, scoped(r.School, bson.M{
+			"date": bson.M{"$gte": d.StartDate, "$lte": d.EndDate},
+		})); err != nil {
+			return nil, err
+		}
+		if len(lessons) > 0 {
+			docs := make([]interface{}, len(lessons))
+			for i, s := range lessons {
+				s.ID = primitive.NilObjectID
+				s.SchoolID = r.School
+				s.DraftID = nil
+				docs[i] = s
+			}
+			if _, err := published.InsertMany(sc, docs); err != nil {
+				return nil, err
+			}
+		}
+		_, err = r.Lessons.DeleteMany(sc, scoped(r.School, bson.M{"draft_id": d.ID}))
+		return nil, err
+	})
+	if err != nil {
+		return err
+	}
+	d.Status, d.PublishedAt = models.DraftPublished, &now
+	return nil
+}
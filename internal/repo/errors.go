@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Domain errors returned by the repositories. Callers test them with
+// errors.Is; the handlers map them onto HTTP statuses.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("already exists")
+	ErrValidation = errors.New("invalid input")
+	// ErrNoTransactions is returned by changes that must be atomic when
+	// MongoDB runs standalone rather than as a replica set.
+	ErrNoTransactions = errors.New("this change needs MongoDB transactions, which require a replica set")
+)
+
+// translate maps driver errors onto the domain errors.
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return ErrConflict
+	}
+	return err
+}
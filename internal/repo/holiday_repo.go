@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HolidayRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewHolidayRepo(coll *mongo.Collection) *HolidayRepo {
+	ctx := context.Background()
+
+	dropIndex(ctx, coll, "start_date_1_end_date_1")
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "start_date", Value: 1}, {Key: "end_date", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &HolidayRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *HolidayRepo) For(school string) *HolidayRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *HolidayRepo) Insert(h *models.Holiday) error {
+	h.SchoolID = r.School
+	h.CreatedAt = time.Now()
+	res, err := r.Coll.InsertOne(r.Ctx, h)
+	if err != nil {
+		return err
+	}
+	h.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Find lists the holidays overlapping [from, to]. A zero bound leaves that
+// side open.
+func (r *HolidayRepo) Find(from, to time.Time) ([]models.Holiday, error) {
+	filter := scoped(r.School, nil)
+	if !from.IsZero() {
+		filter["end_date"] = bson.M{"$gte": from}
+	}
+	if !to.IsZero() {
+		filter["start_date"] = bson.M{"$lte": to}
+	}
+	cur, err := r.Coll.Find(r.Ctx, filter, options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Holiday{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *HolidayRepo) Delete(id primitive.ObjectID) error {
+	res, err := r.Coll.DeleteOne(r.Ctx, scoped(r.School, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
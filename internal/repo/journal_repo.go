@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type JournalRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewJournalRepo(coll *mongo.Collection) *JournalRepo {
+	ctx := context.Background()
+
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "schedule_uuid", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "teacher_nik", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "substitute_nik", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+	})
+
+	return &JournalRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *JournalRepo) For(school string) *JournalRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Save records the journal entry of a lesson, replacing the previous one.
+func (r *JournalRepo) Save(e *models.JournalEntry) error {
+	e.SchoolID = r.School
+	e.RecordedAt = time.Now()
+	_, err := r.Coll.ReplaceOne(r.Ctx, scoped(r.School, bson.M{"schedule_uuid": e.ScheduleUUID}), e,
+		options.Replace().SetUpsert(true))
+	return translate(err)
+}
+
+func (r *JournalRepo) Get(uuid string) (*models.JournalEntry, error) {
+	var e models.JournalEntry
+	if err := r.Coll.FindOne(r.Ctx, scoped(r.School, bson.M{"schedule_uuid": uuid})).Decode(&e); err != nil {
+		return nil, translate(err)
+	}
+	return &e, nil
+}
+
+// Find lists entries matching filter by day.
+func (r *JournalRepo) Find(filter bson.M) ([]models.JournalEntry, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(r.School, filter),
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "schedule_uuid", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.JournalEntry{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Of returns the journal of the lessons rows.
+func (r *JournalRepo) Of(rows []models.Schedule) (models.Journal, error) {
+	if len(rows) == 0 {
+		return models.Journal{}, nil
+	}
+	uuids := make([]string, len(rows))
+	for i, s := range rows {
+		uuids[i] = s.UUID
+	}
+	entries, err := r.Find(bson.M{"schedule_uuid": bson.M{"$in": uuids}})
+	if err != nil {
+		return nil, err
+	}
+	return models.NewJournal(entries), nil
+}
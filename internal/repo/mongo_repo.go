@@ -2,25 +2,37 @@ package repo
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"time"
 
 	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MongoRepo stores the lessons of one school; For switches schools. A
+// repo returned by InDraft works on the lessons of one draft instead of
+// the published ones.
 type MongoRepo struct {
-	Coll *mongo.Collection
-	Ctx  context.Context
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+	Draft  primitive.ObjectID
 }
 
 func NewMongoRepo(coll *mongo.Collection) *MongoRepo {
 	ctx := context.Background()
 
-	// Index by date + class_code
+	dropIndex(ctx, coll, "date_1_class_code_1")
+	dropIndex(ctx, coll, "date_1_teacher_nik_1")
+
+	// Index by school + date + class_code
 	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
+			{Key: "school_id", Value: 1},
 			{Key: "date", Value: 1},
 			{Key: "class_code", Value: 1},
 		},
@@ -29,22 +41,58 @@ func NewMongoRepo(coll *mongo.Collection) *MongoRepo {
 
 	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
+			{Key: "school_id", Value: 1},
 			{Key: "date", Value: 1},
 			{Key: "teacher_nik", Value: 1},
 		},
 		Options: options.Index().SetBackground(true),
 	})
 
-	return &MongoRepo{Coll: coll, Ctx: ctx}
+	return &MongoRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *MongoRepo) For(school string) *MongoRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// InDraft returns a copy of r working on the lessons of draft, which are
+// stored in coll.
+func (r *MongoRepo) InDraft(coll *mongo.Collection, draft primitive.ObjectID) *MongoRepo {
+	c := *r
+	c.Coll = coll
+	c.Draft = draft
+	return &c
+}
+
+// Scope restricts filter to r's school and, in a draft, to the draft's
+// lessons. Handlers querying Coll directly must pass every filter through
+// it.
+func (r *MongoRepo) Scope(filter bson.M) bson.M {
+	filter = scoped(r.School, filter)
+	if !r.Draft.IsZero() {
+		filter["draft_id"] = r.Draft
+	}
+	return filter
 }
 
 func (r *MongoRepo) Insert(s *models.Schedule) error {
+	if s.UUID == "" {
+		return fmt.Errorf("%w: schedule without uuid", ErrValidation)
+	}
+	s.SchoolID = r.School
+	if !r.Draft.IsZero() {
+		draft := r.Draft
+		s.DraftID = &draft
+	}
 	_, err := r.Coll.InsertOne(r.Ctx, s)
-	return err
+	return translate(err)
 }
 
 func (r *MongoRepo) FindAll() ([]models.Schedule, error) {
-	cur, err := r.Coll.Find(r.Ctx, bson.D{})
+	cur, err := r.Coll.Find(r.Ctx, r.Scope(nil))
 	if err != nil {
 		return nil, err
 	}
@@ -59,30 +107,70 @@ func (r *MongoRepo) FindAll() ([]models.Schedule, error) {
 
 func (r *MongoRepo) FindByUUID(uuid string) (*models.Schedule, error) {
 	var s models.Schedule
-	if err := r.Coll.FindOne(r.Ctx, bson.M{"uuid": uuid}).Decode(&s); err != nil {
-		return nil, err
+	if err := r.Coll.FindOne(r.Ctx, r.Scope(bson.M{"uuid": uuid})).Decode(&s); err != nil {
+		return nil, translate(err)
 	}
 	return &s, nil
 }
 
 func (r *MongoRepo) UpdateByUUID(uuid string, update bson.M) error {
-	res, err := r.Coll.UpdateOne(r.Ctx, bson.M{"uuid": uuid}, bson.M{"$set": update})
+	res, err := r.Coll.UpdateOne(r.Ctx, r.Scope(bson.M{"uuid": uuid}), bson.M{"$set": update})
 	if err != nil {
 		return err
 	}
 	if res.MatchedCount == 0 {
-		return errors.New("not found")
+		return ErrNotFound
 	}
 	return nil
 }
 
 func (r *MongoRepo) DeleteByUUID(uuid string) error {
-	res, err := r.Coll.DeleteOne(r.Ctx, bson.M{"uuid": uuid})
+	res, err := r.Coll.DeleteOne(r.Ctx, r.Scope(bson.M{"uuid": uuid}))
 	if err != nil {
 		return err
 	}
 	if res.DeletedCount == 0 {
-		return errors.New("not found")
+		return ErrNotFound
 	}
 	return nil
 }
+
+// UpdateManyByUUID applies every update in one transaction: either all
+// lessons change or none does.
+func (r *MongoRepo) UpdateManyByUUID(updates map[string]bson.M) error {
+	if len(updates) == 0 {
+		return fmt.Errorf("%w: no updates", ErrValidation)
+	}
+	return withTransaction(r.Ctx, r.Coll.Database().Client(), func(sc mongo.SessionContext) (interface{}, error) {
+		for uuid, update := range updates {
+			res, err := r.Coll.UpdateOne(sc, r.Scope(bson.M{"uuid": uuid}), bson.M{"$set": update})
+			if err != nil {
+				return nil, err
+			}
+			if res.MatchedCount == 0 {
+				return nil, ErrNotFound
+			}
+		}
+		return nil, nil
+	})
+}
+
+// DeleteManyByUUID deletes the given lessons and returns how many existed.
+func (r *MongoRepo) DeleteManyByUUID(uuids []string) (int64, error) {
+	res, err := r.Coll.DeleteMany(r.Ctx, r.Scope(bson.M{"uuid": bson.M{"$in": uuids}}))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// LinkTerm links the lessons dated within [start, end] to term.
+func (r *MongoRepo) LinkTerm(start, end time.Time, term primitive.ObjectID) (int64, error) {
+	res, err := r.Coll.UpdateMany(r.Ctx,
+		r.Scope(bson.M{"date": bson.M{"$gte": start, "$lte": end}}),
+		bson.M{"$set": bson.M{"term_id": term}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SchoolRepo stores the per-school settings, keyed by school ID.
+type SchoolRepo struct {
+	Coll *mongo.Collection
+	Ctx  context.Context
+}
+
+func NewSchoolRepo(coll *mongo.Collection) *SchoolRepo {
+	return &SchoolRepo{Coll: coll, Ctx: context.Background()}
+}
+
+func (r *SchoolRepo) Get(id string) (*models.School, error) {
+	var s models.School
+	if err := r.Coll.FindOne(r.Ctx, bson.M{"_id": id}).Decode(&s); err != nil {
+		return nil, translate(err)
+	}
+	return &s, nil
+}
+
+// Upsert stores the settings of s.ID, replacing previous ones.
+func (r *SchoolRepo) Upsert(s *models.School) error {
+	if s.ID == "" {
+		return ErrValidation
+	}
+	s.UpdatedAt = time.Now()
+	_, err := r.Coll.ReplaceOne(r.Ctx, bson.M{"_id": s.ID}, s, options.Replace().SetUpsert(true))
+	return translate(err)
+}
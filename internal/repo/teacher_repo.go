@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TeacherRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewTeacherRepo(coll *mongo.Collection) *TeacherRepo {
+	ctx := context.Background()
+
+	// A NIK is unique within a school; two schools may employ the same
+	// teacher.
+	dropIndex(ctx, coll, "nik_1")
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "nik", Value: 1}},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	})
+
+	return &TeacherRepo{Coll: coll, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *TeacherRepo) For(school string) *TeacherRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Upsert creates or replaces the profile of t.NIK.
+func (r *TeacherRepo) Upsert(t *models.Teacher) error {
+	now := time.Now()
+	t.SchoolID = r.School
+	t.UpdatedAt = now
+	_, err := r.Coll.UpdateOne(r.Ctx, scoped(r.School, bson.M{"nik": t.NIK}), bson.M{
+		"$set": bson.M{
+			"name":            t.Name,
+			"email":           t.Email,
+			"employment_type": t.EmploymentType,
+			"min_weekly_jp":   t.MinWeeklyJP,
+			"max_weekly_jp":   t.MaxWeeklyJP,
+			"max_daily_jp":    t.MaxDailyJP,
+			"updated_at":      now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}, options.Update().SetUpsert(true))
+	return translate(err)
+}
+
+func (r *TeacherRepo) FindAll() ([]models.Teacher, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(r.School, nil), options.Find().SetSort(bson.D{{Key: "nik", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Teacher{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *TeacherRepo) FindByNIK(nik string) (*models.Teacher, error) {
+	var t models.Teacher
+	if err := r.Coll.FindOne(r.Ctx, scoped(r.School, bson.M{"nik": nik})).Decode(&t); err != nil {
+		return nil, translate(err)
+	}
+	return &t, nil
+}
+
+type RateRepo struct {
+	Coll   *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewRateRepo(coll *mongo.Collection) *RateRepo {
+	return &RateRepo{Coll: coll, Ctx: context.Background(), School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *RateRepo) For(school string) *RateRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+// Upsert stores the rate for rt.TeacherNIK or, when that is empty, for
+// rt.EmploymentType, replacing any previous rate of the same scope. rt is
+// updated to the stored rate.
+func (r *RateRepo) Upsert(rt *models.HonorariumRate) error {
+	if rt.TeacherNIK == "" && rt.EmploymentType == "" {
+		return fmt.Errorf("%w: rate needs teacher_nik or employment_type", ErrValidation)
+	}
+	filter := bson.M{"teacher_nik": rt.TeacherNIK}
+	if rt.TeacherNIK == "" {
+		filter = bson.M{"teacher_nik": bson.M{"$exists": false}, "employment_type": rt.EmploymentType}
+	}
+	rt.SchoolID = r.School
+	rt.UpdatedAt = time.Now()
+	rt.ID = primitive.NilObjectID
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+	return translate(r.Coll.FindOneAndReplace(r.Ctx, scoped(r.School, filter), rt, opts).Decode(rt))
+}
+
+func (r *RateRepo) FindAll() ([]models.HonorariumRate, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(r.School, nil))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.HonorariumRate{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *RateRepo) Delete(id primitive.ObjectID) error {
+	res, err := r.Coll.DeleteOne(r.Ctx, scoped(r.School, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
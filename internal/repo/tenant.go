@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scoped restricts filter to the documents of school.
+func scoped(school string, filter bson.M) bson.M {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	filter[tenant.Key] = school
+	return filter
+}
+
+// dropIndex removes an index that predates tenancy. Missing indexes are
+// ignored.
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) {
+	_, _ = coll.Indexes().DropOne(ctx, name)
+}
+
+// AdoptOrphans assigns the documents written before tenancy to the default
+// school so they stay visible to the legacy API key.
+func AdoptOrphans(ctx context.Context, colls ...*mongo.Collection) error {
+	for _, coll := range colls {
+		_, err := coll.UpdateMany(ctx,
+			bson.M{tenant.Key: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{tenant.Key: tenant.Default}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
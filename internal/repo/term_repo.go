@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TermRepo stores academic years and their terms.
+type TermRepo struct {
+	Years  *mongo.Collection
+	Terms  *mongo.Collection
+	Ctx    context.Context
+	School string
+}
+
+func NewTermRepo(years, terms *mongo.Collection) *TermRepo {
+	ctx := context.Background()
+
+	_, _ = years.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	})
+	_, _ = terms.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "start_date", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+
+	return &TermRepo{Years: years, Terms: terms, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *TermRepo) For(school string) *TermRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *TermRepo) InsertYear(y *models.AcademicYear) error {
+	y.SchoolID = r.School
+	y.CreatedAt = time.Now()
+	res, err := r.Years.InsertOne(r.Ctx, y)
+	if err != nil {
+		return translate(err)
+	}
+	y.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *TermRepo) FindYears() ([]models.AcademicYear, error) {
+	cur, err := r.Years.Find(r.Ctx, scoped(r.School, nil),
+		options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.AcademicYear{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *TermRepo) GetYear(id primitive.ObjectID) (*models.AcademicYear, error) {
+	var y models.AcademicYear
+	if err := r.Years.FindOne(r.Ctx, scoped(r.School, bson.M{"_id": id})).Decode(&y); err != nil {
+		return nil, translate(err)
+	}
+	return &y, nil
+}
+
+func (r *TermRepo) Insert(t *models.Term) error {
+	t.SchoolID = r.School
+	t.CreatedAt = time.Now()
+	res, err := r.Terms.InsertOne(r.Ctx, t)
+	if err != nil {
+		return translate(err)
+	}
+	t.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Find lists terms in date order, optionally of one academic year.
+func (r *TermRepo) Find(yearID primitive.ObjectID) ([]models.Term, error) {
+	filter := scoped(r.School, nil)
+	if !yearID.IsZero() {
+		filter["academic_year_id"] = yearID
+	}
+	cur, err := r.Terms.Find(r.Ctx, filter, options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Term{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *TermRepo) Get(id primitive.ObjectID) (*models.Term, error) {
+	var t models.Term
+	if err := r.Terms.FindOne(r.Ctx, scoped(r.School, bson.M{"_id": id})).Decode(&t); err != nil {
+		return nil, translate(err)
+	}
+	return &t, nil
+}
+
+// SetLocked locks or unlocks a term and returns its new state.
+func (r *TermRepo) SetLocked(id primitive.ObjectID, locked bool) (*models.Term, error) {
+	update := bson.M{"$set": bson.M{"locked": true, "locked_at": time.Now()}}
+	if !locked {
+		update = bson.M{"$set": bson.M{"locked": false}, "$unset": bson.M{"locked_at": ""}}
+	}
+	var t models.Term
+	err := r.Terms.FindOneAndUpdate(r.Ctx, scoped(r.School, bson.M{"_id": id}), update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&t)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &t, nil
+}
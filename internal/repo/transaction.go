@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// supports caches per client whether the server runs transactions.
+var supports sync.Map
+
+// Transactions reports whether client's server supports multi-document
+// transactions: a replica set member or a mongos. A standalone mongod,
+// the default local install, does not; swaps, bulk shifts and draft
+// publishing then fail with ErrNoTransactions.
+func Transactions(ctx context.Context, client *mongo.Client) (bool, error) {
+	if ok, known := supports.Load(client); known {
+		return ok.(bool), nil
+	}
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+	ok := hello.SetName != "" || hello.Msg == "isdbgrid"
+	supports.Store(client, ok)
+	return ok, nil
+}
+
+// withTransaction runs fn in a transaction on client, or fails with
+// ErrNoTransactions when the server cannot run one.
+func withTransaction(ctx context.Context, client *mongo.Client, fn func(sc mongo.SessionContext) (interface{}, error)) error {
+	ok, err := Transactions(ctx, client)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoTransactions
+	}
+	sess, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+	_, err = sess.WithTransaction(ctx, fn)
+	return err
+}
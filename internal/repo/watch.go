@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// change is the part of a change stream document Watch reads.
+type change struct {
+	OperationType string           `bson:"operationType"`
+	FullDocument  *models.Schedule `bson:"fullDocument"`
+	Before        *models.Schedule `bson:"fullDocumentBeforeChange"`
+}
+
+// Watch opens a change stream on the schedules collection and publishes
+// each insert, update and delete until ctx ends. It fails at once when
+// the server has no change streams (a standalone mongod).
+//
+// Deletes carry the lesson, and updates its previous state, only when the
+// collection records pre-images (changeStreamPreAndPostImages, MongoDB
+// 6.0+); deletes without one are skipped since no filter could match them.
+func Watch(ctx context.Context, coll *mongo.Collection, publish func(events.Event)) error {
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	cs, err := coll.Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer cs.Close(context.Background())
+		for cs.Next(ctx) {
+			var ch change
+			if err := cs.Decode(&ch); err != nil {
+				log.Println("change stream:", err)
+				continue
+			}
+			if e, ok := eventOf(ch); ok {
+				publish(e)
+			}
+		}
+		if err := cs.Err(); err != nil && ctx.Err() == nil {
+			log.Println("change stream stopped:", err)
+		}
+	}()
+	return nil
+}
+
+func eventOf(ch change) (events.Event, bool) {
+	e := events.Event{Schedule: ch.FullDocument}
+	switch ch.OperationType {
+	case "insert":
+		e.Type = events.Created
+	case "update", "replace":
+		e.Type = events.Updated
+		e.Previous = ch.Before
+	case "delete":
+		e.Type = events.Deleted
+		e.Schedule = ch.Before
+	default:
+		return e, false
+	}
+	if e.Schedule == nil {
+		return e, false
+	}
+	e.SchoolID, e.UUID = e.Schedule.SchoolID, e.Schedule.UUID
+	return e, true
+}
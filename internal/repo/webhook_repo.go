@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepo stores webhooks and their deliveries. Besides the usual
+// methods scoped to School it implements webhook.Store, whose methods act
+// across schools for the dispatcher.
+type WebhookRepo struct {
+	Coll       *mongo.Collection
+	Deliveries *mongo.Collection
+	Ctx        context.Context
+	School     string
+}
+
+func NewWebhookRepo(coll, deliveries *mongo.Collection) *WebhookRepo {
+	ctx := context.Background()
+
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "active", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	})
+	_, _ = deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "school_id", Value: 1}, {Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetBackground(true),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+	})
+
+	return &WebhookRepo{Coll: coll, Deliveries: deliveries, Ctx: ctx, School: tenant.Default}
+}
+
+// For returns a copy of r acting for school.
+func (r *WebhookRepo) For(school string) *WebhookRepo {
+	c := *r
+	c.School = school
+	return &c
+}
+
+func (r *WebhookRepo) Insert(w *models.Webhook) error {
+	w.SchoolID = r.School
+	w.CreatedAt = time.Now()
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	res, err := r.Coll.InsertOne(r.Ctx, w)
+	if err != nil {
+		return err
+	}
+	w.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *WebhookRepo) Find() ([]models.Webhook, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(r.School, nil), options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.Webhook{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *WebhookRepo) Get(id primitive.ObjectID) (*models.Webhook, error) {
+	var w models.Webhook
+	if err := r.Coll.FindOne(r.Ctx, scoped(r.School, bson.M{"_id": id})).Decode(&w); err != nil {
+		return nil, translate(err)
+	}
+	return &w, nil
+}
+
+func (r *WebhookRepo) SetActive(id primitive.ObjectID, active bool) error {
+	res, err := r.Coll.UpdateOne(r.Ctx, scoped(r.School, bson.M{"_id": id}), bson.M{"$set": bson.M{"active": active}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a webhook. Its delivery log stays; pending deliveries
+// become dead letters when they are next attempted.
+func (r *WebhookRepo) Delete(id primitive.ObjectID) error {
+	res, err := r.Coll.DeleteOne(r.Ctx, scoped(r.School, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindDeliveries lists deliveries newest first, optionally of one webhook
+// and one status.
+func (r *WebhookRepo) FindDeliveries(webhookID *primitive.ObjectID, status string, limit int64) ([]models.WebhookDelivery, error) {
+	filter := scoped(r.School, nil)
+	if webhookID != nil {
+		filter["webhook_id"] = *webhookID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	cur, err := r.Deliveries.Find(r.Ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	out := []models.WebhookDelivery{}
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Retry puts a dead delivery back in the queue, due now. It fails with
+// ErrConflict when the delivery is not dead.
+func (r *WebhookRepo) Retry(id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := r.Deliveries.FindOneAndUpdate(r.Ctx,
+		scoped(r.School, bson.M{"_id": id, "status": models.DeliveryDead}),
+		bson.M{"$set": bson.M{"status": models.DeliveryPending, "next_attempt_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := r.getDelivery(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *WebhookRepo) getDelivery(id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := r.Deliveries.FindOne(r.Ctx, scoped(r.School, bson.M{"_id": id})).Decode(&d); err != nil {
+		return nil, translate(err)
+	}
+	return &d, nil
+}
+
+// Subscribed returns the active webhooks of school that want typ.
+func (r *WebhookRepo) Subscribed(school, typ string) ([]models.Webhook, error) {
+	cur, err := r.Coll.Find(r.Ctx, scoped(school, bson.M{
+		"active": true,
+		"$or":    bson.A{bson.M{"events": typ}, bson.M{"events": bson.M{"$size": 0}}},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(r.Ctx)
+
+	var out []models.Webhook
+	if err := cur.All(r.Ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Webhook returns a webhook of school, or nil when it no longer exists.
+func (r *WebhookRepo) Webhook(school string, id primitive.ObjectID) (*models.Webhook, error) {
+	w, err := r.For(school).Get(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return w, err
+}
+
+func (r *WebhookRepo) Enqueue(d *models.WebhookDelivery) error {
+	res, err := r.Deliveries.InsertOne(r.Ctx, d)
+	if err != nil {
+		return err
+	}
+	d.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Claim returns the pending delivery due longest, or nil when none is due,
+// and postpones it by lease so other dispatchers skip it meanwhile.
+func (r *WebhookRepo) Claim(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := r.Deliveries.FindOneAndUpdate(r.Ctx,
+		bson.M{"status": models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_attempt_at", Value: 1}})).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Save stores the outcome of an attempt.
+func (r *WebhookRepo) Save(d *models.WebhookDelivery) error {
+	_, err := r.Deliveries.UpdateOne(r.Ctx, bson.M{"_id": d.ID}, bson.M{"$set": bson.M{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"next_attempt_at": d.NextAttemptAt,
+		"delivered_at":    d.DeliveredAt,
+	}})
+	return err
+}
@@ -0,0 +1,279 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+)
+
+// Grouping dimensions.
+const (
+	DimTeacher  = "teacher"
+	DimSubject  = "subject"
+	DimClass    = "class"
+	DimMonth    = "month"
+	DimSemester = "semester"
+)
+
+// Measures.
+const (
+	MeasureLessons = "lessons"
+	MeasureJP      = "jp"
+	MeasureClasses = "classes"
+)
+
+var dimColumns = map[string][]string{
+	DimTeacher:  {"teacher_nik", "teacher_name"},
+	DimSubject:  {"subject_code"},
+	DimClass:    {"class_code"},
+	DimMonth:    {"month"},
+	DimSemester: {"semester"},
+}
+
+var measureColumns = map[string]bool{
+	MeasureLessons: true,
+	MeasureJP:      true,
+	MeasureClasses: true,
+}
+
+// Query describes one report: which schedules to include, how to group
+// them and what to measure per group.
+type Query struct {
+	Start      time.Time
+	End        time.Time
+	Dimensions []string
+	Measures   []string
+	// Match holds extra equality filters such as teacher_nik or class_code.
+	Match bson.M
+}
+
+// Validate fills defaults and rejects unknown dimensions and measures.
+func (q *Query) Validate() error {
+	if q.End.Before(q.Start) {
+		return fmt.Errorf("end_date must not be before start_date")
+	}
+	if len(q.Dimensions) == 0 {
+		q.Dimensions = []string{DimTeacher}
+	}
+	if len(q.Measures) == 0 {
+		q.Measures = []string{MeasureLessons, MeasureJP}
+	}
+	seen := map[string]bool{}
+	for _, d := range q.Dimensions {
+		if _, ok := dimColumns[d]; !ok {
+			return fmt.Errorf("unknown dimension %q", d)
+		}
+		if seen[d] {
+			return fmt.Errorf("duplicate dimension %q", d)
+		}
+		seen[d] = true
+	}
+	for _, m := range q.Measures {
+		if !measureColumns[m] {
+			return fmt.Errorf("unknown measure %q", m)
+		}
+	}
+	return nil
+}
+
+// Table is the result of a report, ready to be written as JSON, CSV or XLSX.
+type Table struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// Records returns the rows as column-keyed objects.
+func (t Table) Records() []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		rec := make(map[string]interface{}, len(t.Columns))
+		for i, col := range t.Columns {
+			rec[col] = row[i]
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// Group is one row of the aggregation pipeline. Lessons are grouped by the
+// raw fields the dimensions need plus the lesson times, so that JP can be
+// computed with the same rules as everywhere else before the final merge.
+type Group struct {
+	TeacherNIK  string   `bson:"teacher_nik"`
+	TeacherName string   `bson:"teacher_name"`
+	SubjectCode string   `bson:"subject_code"`
+	ClassCode   string   `bson:"class_code"`
+	Month       string   `bson:"month"`
+	TimeStart   string   `bson:"time_start"`
+	TimeEnd     string   `bson:"time_end"`
+	Lessons     int      `bson:"lessons"`
+	Classes     []string `bson:"classes"`
+}
+
+func (q Query) has(dim string) bool {
+	for _, d := range q.Dimensions {
+		if d == dim {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipeline builds the Mongo aggregation that does the heavy lifting.
+func (q Query) Pipeline() mongo.Pipeline {
+	match := bson.M{"date": bson.M{"$gte": q.Start, "$lte": q.End}}
+	for k, v := range q.Match {
+		match[k] = v
+	}
+
+	id := bson.M{"time_start": "$time_start", "time_end": "$time_end"}
+	if q.has(DimTeacher) {
+		id["teacher_nik"] = "$teacher_nik"
+	}
+	if q.has(DimSubject) {
+		id["subject_code"] = "$subject_code"
+	}
+	if q.has(DimClass) {
+		id["class_code"] = "$class_code"
+	}
+	if q.has(DimMonth) || q.has(DimSemester) {
+		id["month"] = bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$date"}}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          id,
+			"teacher_name": bson.M{"$first": "$teacher_name"},
+			"lessons":      bson.M{"$sum": 1},
+			"classes":      bson.M{"$addToSet": "$class_code"},
+		}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+			"$_id",
+			bson.M{"teacher_name": "$teacher_name", "lessons": "$lessons", "classes": "$classes"},
+		}}}}},
+	}
+}
+
+// Semester maps a YYYY-MM month onto the Indonesian academic calendar:
+// July-December is the odd (ganjil) semester, January-June the even
+// (genap) one of the academic year that started the previous July.
+func Semester(month string) string {
+	parts := strings.SplitN(month, "-", 2)
+	if len(parts) != 2 {
+		return month
+	}
+	y, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return month
+	}
+	if m >= 7 {
+		return fmt.Sprintf("%d/%d Ganjil", y, y+1)
+	}
+	return fmt.Sprintf("%d/%d Genap", y-1, y)
+}
+
+type bucket struct {
+	key     []interface{}
+	lessons int
+	jp      float64
+	classes map[string]struct{}
+}
+
+// Merge folds pipeline groups into the final table.
+func Merge(q Query, calc jp.Calculator, groups []Group) Table {
+	var t Table
+	for _, d := range q.Dimensions {
+		t.Columns = append(t.Columns, dimColumns[d]...)
+	}
+	t.Columns = append(t.Columns, q.Measures...)
+
+	buckets := map[string]*bucket{}
+	var order []string
+	for _, g := range groups {
+		var key []interface{}
+		for _, d := range q.Dimensions {
+			switch d {
+			case DimTeacher:
+				key = append(key, g.TeacherNIK, g.TeacherName)
+			case DimSubject:
+				key = append(key, g.SubjectCode)
+			case DimClass:
+				key = append(key, g.ClassCode)
+			case DimMonth:
+				key = append(key, g.Month)
+			case DimSemester:
+				key = append(key, Semester(g.Month))
+			}
+		}
+		// The teacher name is informational; do not split on it.
+		var sk []string
+		for i, v := range key {
+			if q.has(DimTeacher) && t.Columns[i] == "teacher_name" {
+				continue
+			}
+			sk = append(sk, fmt.Sprint(v))
+		}
+		k := strings.Join(sk, "\x00")
+
+		b, ok := buckets[k]
+		if !ok {
+			b = &bucket{key: key, classes: map[string]struct{}{}}
+			buckets[k] = b
+			order = append(order, k)
+		}
+
+		lessonJP := 1.0
+		if m, err := jp.Minutes(g.TimeStart, g.TimeEnd); err == nil {
+			lessonJP = calc.FromMinutes(m)
+		}
+		b.lessons += g.Lessons
+		b.jp += lessonJP * float64(g.Lessons)
+		for _, cc := range g.Classes {
+			b.classes[cc] = struct{}{}
+		}
+	}
+
+	sort.Strings(order)
+	for _, k := range order {
+		b := buckets[k]
+		row := append([]interface{}{}, b.key...)
+		for _, m := range q.Measures {
+			switch m {
+			case MeasureLessons:
+				row = append(row, b.lessons)
+			case MeasureJP:
+				row = append(row, b.jp)
+			case MeasureClasses:
+				row = append(row, len(b.classes))
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if t.Rows == nil {
+		t.Rows = [][]interface{}{}
+	}
+	return t
+}
+
+// Run executes the query against the schedules collection.
+func Run(ctx context.Context, coll *mongo.Collection, calc jp.Calculator, q Query) (Table, error) {
+	cur, err := coll.Aggregate(ctx, q.Pipeline())
+	if err != nil {
+		return Table{}, err
+	}
+	var groups []Group
+	if err := cur.All(ctx, &groups); err != nil {
+		return Table{}, err
+	}
+	return Merge(q, calc, groups), nil
+}
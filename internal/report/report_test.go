@@ -0,0 +1,55 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/report"
+)
+
+func TestValidate(t *testing.T) {
+	q := report.Query{}
+	assert.NoError(t, q.Validate())
+	assert.Equal(t, []string{report.DimTeacher}, q.Dimensions)
+
+	q = report.Query{Dimensions: []string{"room"}}
+	assert.Error(t, q.Validate())
+
+	q = report.Query{Measures: []string{"money"}}
+	assert.Error(t, q.Validate())
+}
+
+func TestSemester(t *testing.T) {
+	assert.Equal(t, "2024/2025 Ganjil", report.Semester("2024-07"))
+	assert.Equal(t, "2024/2025 Ganjil", report.Semester("2024-12"))
+	assert.Equal(t, "2023/2024 Genap", report.Semester("2024-01"))
+}
+
+func TestMerge(t *testing.T) {
+	calc := jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+	q := report.Query{
+		Dimensions: []string{report.DimTeacher, report.DimSemester},
+		Measures:   []string{report.MeasureLessons, report.MeasureJP, report.MeasureClasses},
+	}
+	groups := []report.Group{
+		{TeacherNIK: "1", TeacherName: "Budi", Month: "2024-08", TimeStart: "07:00:00", TimeEnd: "08:30:00", Lessons: 2, Classes: []string{"A"}},
+		{TeacherNIK: "1", TeacherName: "Budi", Month: "2024-09", TimeStart: "07:00:00", TimeEnd: "07:45:00", Lessons: 3, Classes: []string{"A", "B"}},
+		{TeacherNIK: "2", TeacherName: "Sari", Month: "2024-02", TimeStart: "07:00:00", TimeEnd: "07:45:00", Lessons: 1, Classes: []string{"C"}},
+	}
+	tbl := report.Merge(q, calc, groups)
+
+	assert.Equal(t, []string{"teacher_nik", "teacher_name", "semester", "lessons", "jp", "classes"}, tbl.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"1", "Budi", "2024/2025 Ganjil", 5, 7.0, 2},
+		{"2", "Sari", "2023/2024 Genap", 1, 1.0, 1},
+	}, tbl.Rows)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&buf, tbl))
+	assert.Equal(t, "teacher_nik,teacher_name,semester,lessons,jp,classes\n"+
+		"1,Budi,2024/2025 Ganjil,5,7,2\n"+
+		"2,Sari,2023/2024 Genap,1,1,1\n", buf.String())
+}
@@ -0,0 +1,93 @@
+// Package tenant resolves which school a request acts for. Every school
+// has its own API keys; all data is stored with the school's ID and every
+// query is scoped to it.
+package tenant
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default is the school of the single-school API_KEY and of data stored
+// before multi-school support.
+const Default = "default"
+
+// Key is the gin context key holding the school ID.
+const Key = "school_id"
+
+// Keys maps API keys to school IDs.
+type Keys map[string]string
+
+// ParseKeys reads "school:key,school:key" pairs as found in
+// SCHOOL_API_KEYS. Malformed pairs are skipped.
+func ParseKeys(s string) Keys {
+	out := Keys{}
+	for _, pair := range strings.Split(s, ",") {
+		school, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		school, key = strings.TrimSpace(school), strings.TrimSpace(key)
+		if !ok || school == "" || key == "" {
+			continue
+		}
+		out[key] = school
+	}
+	return out
+}
+
+// FromEnv returns the keys of SCHOOL_API_KEYS and SCHOOL_ADMIN_KEYS plus
+// the single-school API_KEY (default SECRET123) for the Default school,
+// unless one of the others already assigns that key.
+func FromEnv() Keys {
+	keys := ParseKeys(os.Getenv("SCHOOL_API_KEYS"))
+	for key, school := range AdminFromEnv() {
+		keys[key] = school
+	}
+	legacy := os.Getenv("API_KEY")
+	if legacy == "" {
+		legacy = "SECRET123"
+	}
+	if _, taken := keys[legacy]; !taken {
+		keys[legacy] = Default
+	}
+	return keys
+}
+
+// AdminFromEnv returns the keys of SCHOOL_ADMIN_KEYS, the keys of a
+// school's administrators. Besides everything a school key may do, they
+// review teachers' change requests.
+func AdminFromEnv() Keys {
+	return ParseKeys(os.Getenv("SCHOOL_ADMIN_KEYS"))
+}
+
+// Resolve returns the school of an API key.
+func (k Keys) Resolve(apiKey string) (string, bool) {
+	if apiKey == "" {
+		return "", false
+	}
+	school, ok := k[apiKey]
+	return school, ok
+}
+
+// From returns the school resolved for the request, or Default.
+func From(c *gin.Context) string {
+	if s := c.GetString(Key); s != "" {
+		return s
+	}
+	return Default
+}
+
+// Schools returns the IDs of the schools that have keys, sorted.
+func (k Keys) Schools() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, school := range k {
+		if !seen[school] {
+			seen[school] = true
+			out = append(out, school)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,52 @@
+package tenant_test
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/tenant"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := tenant.ParseKeys(" sma1:key-a, smk2:key-b ,broken,:nokey,noschool:")
+	assert.Equal(t, tenant.Keys{"key-a": "sma1", "key-b": "smk2"}, keys)
+
+	school, ok := keys.Resolve("key-b")
+	assert.True(t, ok)
+	assert.Equal(t, "smk2", school)
+
+	_, ok = keys.Resolve("")
+	assert.False(t, ok)
+	_, ok = keys.Resolve("key-c")
+	assert.False(t, ok)
+}
+
+func TestSchools(t *testing.T) {
+	keys := tenant.Keys{"k1": "smk2", "k2": "sma1", "k3": "smk2"}
+	assert.Equal(t, []string{"sma1", "smk2"}, keys.Schools())
+	assert.Empty(t, tenant.Keys{}.Schools())
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SCHOOL_API_KEYS", "sma1:key-a")
+	t.Setenv("API_KEY", "legacy")
+	assert.Equal(t, tenant.Keys{"key-a": "sma1", "legacy": tenant.Default}, tenant.FromEnv())
+
+	t.Setenv("SCHOOL_ADMIN_KEYS", "sma1:admin-a")
+	assert.Equal(t, tenant.Keys{"key-a": "sma1", "admin-a": "sma1", "legacy": tenant.Default}, tenant.FromEnv())
+	assert.Equal(t, tenant.Keys{"admin-a": "sma1"}, tenant.AdminFromEnv())
+	t.Setenv("SCHOOL_ADMIN_KEYS", "")
+
+	// An explicitly assigned legacy key keeps its school.
+	t.Setenv("SCHOOL_API_KEYS", "sma1:legacy")
+	assert.Equal(t, tenant.Keys{"legacy": "sma1"}, tenant.FromEnv())
+}
+
+func TestFrom(t *testing.T) {
+	c := &gin.Context{}
+	assert.Equal(t, tenant.Default, tenant.From(c))
+	c.Set(tenant.Key, "sma1")
+	assert.Equal(t, "sma1", tenant.From(c))
+}
@@ -0,0 +1,192 @@
+package timetable
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/mghazyfawazh/EGS/internal/bell"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+var dayNames = []string{"Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// Header is printed at the top of every page.
+type Header struct {
+	SchoolName    string
+	SchoolAddress string
+}
+
+type cellKey struct {
+	Day   int
+	JamKe int
+}
+
+// Grid is one weekly timetable page: days as columns, periods as rows.
+type Grid struct {
+	Title     string
+	WeekStart time.Time
+	Days      []time.Time
+	Periods   []models.BellPeriod
+	cells     map[cellKey][]string
+}
+
+// BuildGrid places rows of the week starting at weekStart into a grid.
+// label decides what is printed in a cell, e.g. subject and teacher for a
+// class timetable or subject and class for a teacher timetable.
+func BuildGrid(title string, weekStart time.Time, rows []models.Schedule, label func(models.Schedule) string) Grid {
+	g := Grid{
+		Title:     title,
+		WeekStart: weekStart,
+		cells:     map[cellKey][]string{},
+	}
+
+	// Monday to Saturday are always printed, Sunday only when used.
+	days := 6
+	var inWeek []models.Schedule
+	for _, r := range rows {
+		day := int(r.Date.Sub(weekStart).Hours() / 24)
+		if day < 0 || day > 6 {
+			continue
+		}
+		if day == 6 {
+			days = 7
+		}
+		k := cellKey{Day: day, JamKe: r.JamKe}
+		g.cells[k] = append(g.cells[k], label(r))
+		inWeek = append(inWeek, r)
+	}
+	for i := 0; i < days; i++ {
+		g.Days = append(g.Days, weekStart.AddDate(0, 0, i))
+	}
+
+	// The period times come from the data itself.
+	g.Periods = bell.FromLessons(inWeek)
+
+	for k := range g.cells {
+		sort.Strings(g.cells[k])
+	}
+	return g
+}
+
+// Cell returns the labels placed on the given day index and period.
+func (g Grid) Cell(day, jamKe int) []string {
+	return g.cells[cellKey{Day: day, JamKe: jamKe}]
+}
+
+func shortTime(t string) string {
+	if len(t) == len("15:04:05") {
+		return t[:5]
+	}
+	return t
+}
+
+// Render writes every grid as its own landscape A4 page into a single PDF.
+func Render(w io.Writer, header Header, grids []Grid) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.SetTitle("Jadwal Pelajaran", false)
+
+	if len(grids) == 0 {
+		pdf.AddPage()
+		writeHeader(pdf, header, Grid{Title: "Jadwal Pelajaran"})
+		pdf.SetFont("Helvetica", "I", 10)
+		pdf.CellFormat(0, 8, "Tidak ada jadwal.", "", 1, "L", false, 0, "")
+	}
+	for _, g := range grids {
+		pdf.AddPage()
+		writeHeader(pdf, header, g)
+		writeGrid(pdf, g)
+	}
+	return pdf.Output(w)
+}
+
+func writeHeader(pdf *fpdf.Fpdf, header Header, g Grid) {
+	if header.SchoolName != "" {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 7, header.SchoolName, "", 1, "C", false, 0, "")
+	}
+	if header.SchoolAddress != "" {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, header.SchoolAddress, "", 1, "C", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 7, g.Title, "", 1, "C", false, 0, "")
+	if len(g.Days) > 0 {
+		last := g.Days[len(g.Days)-1]
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, fmt.Sprintf("Pekan %s s.d. %s",
+			g.WeekStart.Format("02-01-2006"), last.Format("02-01-2006")), "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(3)
+}
+
+func writeGrid(pdf *fpdf.Fpdf, g Grid) {
+	left, _, right, bottom := pdf.GetMargins()
+	pageW, pageH := pdf.GetPageSize()
+
+	const firstColW = 26.0
+	const headerH = 8.0
+	dayW := (pageW - left - right - firstColW) / float64(len(g.Days))
+
+	rowH := 14.0
+	if len(g.Periods) > 0 {
+		avail := pageH - pdf.GetY() - bottom - headerH
+		if fit := avail / float64(len(g.Periods)); fit < rowH {
+			rowH = fit
+		}
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(255, 255, 0)
+	pdf.CellFormat(firstColW, headerH, "Jam", "1", 0, "C", true, 0, "")
+	for i, d := range g.Days {
+		ln := 0
+		if i == len(g.Days)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(dayW, headerH, fmt.Sprintf("%s %s", dayNames[i], d.Format("02/01")), "1", ln, "C", true, 0, "")
+	}
+
+	for _, p := range g.Periods {
+		y := pdf.GetY()
+		pdf.SetFont("Helvetica", "B", 9)
+		box(pdf, left, y, firstColW, rowH, []string{
+			fmt.Sprintf("Jam ke-%d", p.JamKe),
+			fmt.Sprintf("%s-%s", shortTime(p.TimeStart), shortTime(p.TimeEnd)),
+		})
+		pdf.SetFont("Helvetica", "", 8)
+		for i := range g.Days {
+			box(pdf, left+firstColW+float64(i)*dayW, y, dayW, rowH, g.Cell(i, p.JamKe))
+		}
+		pdf.SetXY(left, y+rowH)
+	}
+}
+
+// box draws a bordered cell and centres the given lines inside it, wrapping
+// long lines and dropping whatever does not fit.
+func box(pdf *fpdf.Fpdf, x, y, w, h float64, lines []string) {
+	pdf.Rect(x, y, w, h, "D")
+
+	_, fontSize := pdf.GetFontSize()
+	lineH := fontSize * 0.45
+	var wrapped []string
+	for _, l := range lines {
+		for _, part := range pdf.SplitLines([]byte(strings.TrimSpace(l)), w-2) {
+			wrapped = append(wrapped, string(part))
+		}
+	}
+	if max := int(h / lineH); len(wrapped) > max {
+		wrapped = wrapped[:max]
+	}
+
+	top := y + (h-float64(len(wrapped))*lineH)/2
+	for i, l := range wrapped {
+		pdf.SetXY(x, top+float64(i)*lineH)
+		pdf.CellFormat(w, lineH, l, "", 0, "C", false, 0, "")
+	}
+}
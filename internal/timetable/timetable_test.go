@@ -0,0 +1,40 @@
+package timetable_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/timetable"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildGrid(t *testing.T) {
+	rows := []models.Schedule{
+		{SubjectCode: "MTK", Date: day(1), JamKe: 2, TimeStart: "07:45:00", TimeEnd: "08:30:00"},
+		{SubjectCode: "BIN", Date: day(2), JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{SubjectCode: "IPA", Date: day(3), JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{SubjectCode: "OR", Date: day(5), JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:30:00"},
+		{SubjectCode: "OUT", Date: day(8), JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+	}
+	g := timetable.BuildGrid("X-TKJ-1", day(1), rows, func(s models.Schedule) string { return s.SubjectCode })
+
+	assert.Len(t, g.Days, 6)
+	assert.Equal(t, []models.BellPeriod{
+		{JamKe: 1, TimeStart: "07:00:00", TimeEnd: "07:45:00"},
+		{JamKe: 2, TimeStart: "07:45:00", TimeEnd: "08:30:00"},
+	}, g.Periods)
+	assert.Equal(t, []string{"MTK"}, g.Cell(0, 2))
+	assert.Equal(t, []string{"OR"}, g.Cell(4, 1))
+	assert.Empty(t, g.Cell(0, 1))
+
+	var buf bytes.Buffer
+	assert.NoError(t, timetable.Render(&buf, timetable.Header{SchoolName: "SMK Negeri 1"}, []timetable.Grid{g, g}))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF")))
+}
@@ -0,0 +1,64 @@
+// Package tz anchors dates and clock times to the school's time zone.
+//
+// A lesson's calendar day is kept as UTC midnight of that day, which is
+// what the date queries and the week arithmetic work with. The school zone
+// decides which calendar day an instant belongs to and turns a day plus a
+// HH:MM[:SS] clock time into a real instant.
+package tz
+
+import (
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+)
+
+// Default is the zone used when none or an unknown one is configured.
+const Default = "Asia/Jakarta"
+
+// Load returns the named zone, falling back to Default. The error reports
+// an unknown name.
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		name = Default
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		def, _ := time.LoadLocation(Default)
+		return def, err
+	}
+	return loc, nil
+}
+
+// Day returns the calendar day of t in loc as UTC midnight.
+func Day(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the current calendar day at the school.
+func Today(loc *time.Location) time.Time {
+	return Day(time.Now(), loc)
+}
+
+// ParseDate reads a YYYY-MM-DD day, or an RFC 3339 timestamp which is
+// reduced to its calendar day in loc.
+func ParseDate(s string, loc *time.Location) (time.Time, error) {
+	d, err := time.Parse("2006-01-02", s)
+	if err == nil {
+		return d, nil
+	}
+	if t, err2 := time.Parse(time.RFC3339, s); err2 == nil {
+		return Day(t, loc), nil
+	}
+	return time.Time{}, err
+}
+
+// At returns the instant at which the clock time on day reads in loc.
+func At(day time.Time, clock string, loc *time.Location) (time.Time, error) {
+	c, err := jp.ParseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, c.Hour(), c.Minute(), c.Second(), 0, loc), nil
+}
@@ -0,0 +1,59 @@
+package tz_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mghazyfawazh/EGS/internal/tz"
+)
+
+func TestLoad(t *testing.T) {
+	loc, err := tz.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, tz.Default, loc.String())
+
+	loc, err = tz.Load("Asia/Makassar")
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Makassar", loc.String())
+
+	loc, err = tz.Load("Mars/Olympus")
+	assert.Error(t, err)
+	assert.Equal(t, tz.Default, loc.String())
+}
+
+func TestParseDate(t *testing.T) {
+	wib, _ := tz.Load("Asia/Jakarta")
+	want := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	d, err := tz.ParseDate("2024-03-04", wib)
+	require.NoError(t, err)
+	assert.Equal(t, want, d)
+
+	// 18:30 UTC on the 3rd is already the 4th in WIB.
+	d, err = tz.ParseDate("2024-03-03T18:30:00Z", wib)
+	require.NoError(t, err)
+	assert.Equal(t, want, d)
+
+	d, err = tz.ParseDate("2024-03-04T06:00:00+07:00", wib)
+	require.NoError(t, err)
+	assert.Equal(t, want, d)
+
+	_, err = tz.ParseDate("04/03/2024", wib)
+	assert.Error(t, err)
+}
+
+func TestAt(t *testing.T) {
+	wit, _ := tz.Load("Asia/Jayapura")
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	at, err := tz.At(day, "07:30", wit)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 3, 22, 30, 0, 0, time.UTC), at.UTC())
+	assert.Equal(t, day, tz.Day(at, wit))
+
+	_, err = tz.At(day, "half past seven", wit)
+	assert.Error(t, err)
+}
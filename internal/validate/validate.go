@@ -0,0 +1,121 @@
+// Package validate checks lessons field by field before they reach the
+// conflict and workload checks, so that clients get every problem of a
+// request at once instead of a 500 from a time that cannot be parsed.
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mghazyfawazh/EGS/internal/availability"
+	"github.com/mghazyfawazh/EGS/internal/bell"
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Error codes.
+const (
+	CodeRequired = "required"
+	CodeFormat   = "format"
+	CodeRange    = "range"
+	CodeOrder    = "order"
+	CodeUnknown  = "unknown"
+)
+
+// DefaultNIKPattern accepts any non-empty string of digits.
+const DefaultNIKPattern = `^[0-9]+$`
+
+var clockRe = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9](:[0-5][0-9])?$`)
+
+// FieldError is one problem with one field of the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors collects the field errors of a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Add records a problem with field.
+func (e *Errors) Add(field, code, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Rules holds what a lesson is checked against. Empty Periods, Classes or
+// Subjects disable the corresponding check, e.g. while no bell schedule or
+// curriculum has been configured yet.
+type Rules struct {
+	NIK      *regexp.Regexp
+	Periods  []models.BellPeriod
+	Classes  map[string]bool
+	Subjects map[string]bool
+}
+
+// NewRules builds rules from the configured NIK pattern, bell schedule and
+// curriculum, which doubles as the catalogue of known classes and
+// subjects. An invalid pattern falls back to DefaultNIKPattern.
+func NewRules(nikPattern string, periods []models.BellPeriod, reqs []models.CurriculumRequirement) Rules {
+	re, err := regexp.Compile(nikPattern)
+	if nikPattern == "" || err != nil {
+		re = regexp.MustCompile(DefaultNIKPattern)
+	}
+	r := Rules{NIK: re, Periods: periods, Classes: map[string]bool{}, Subjects: map[string]bool{}}
+	for _, req := range reqs {
+		r.Classes[req.ClassCode] = true
+		r.Subjects[req.SubjectCode] = true
+	}
+	return r
+}
+
+// Clock reports whether s is a HH:MM or HH:MM:SS time.
+func Clock(s string) bool {
+	return clockRe.MatchString(s)
+}
+
+// Schedule checks the fields of s and returns nil when all are valid.
+func (r Rules) Schedule(s models.Schedule) Errors {
+	var errs Errors
+
+	startOK := Clock(s.TimeStart)
+	if !startOK {
+		errs.Add("time_start", CodeFormat, "must be HH:MM or HH:MM:SS, got %q", s.TimeStart)
+	}
+	endOK := Clock(s.TimeEnd)
+	if !endOK {
+		errs.Add("time_end", CodeFormat, "must be HH:MM or HH:MM:SS, got %q", s.TimeEnd)
+	}
+	if startOK && endOK {
+		if m, err := jp.Minutes(s.TimeStart, s.TimeEnd); err == nil && m <= 0 {
+			errs.Add("time_end", CodeOrder, "must be after time_start %s", s.TimeStart)
+		}
+	}
+
+	if s.JamKe < 1 {
+		errs.Add("jam_ke", CodeRange, "must be at least 1, got %d", s.JamKe)
+	} else if len(r.Periods) > 0 && !s.Date.IsZero() {
+		if _, ok := bell.Find(r.Periods, availability.ISOWeekday(s.Date), s.JamKe); !ok {
+			errs.Add("jam_ke", CodeRange, "period %d is not in the bell schedule of %s", s.JamKe, s.Date.Weekday())
+		}
+	}
+
+	if r.NIK != nil && !r.NIK.MatchString(s.TeacherNIK) {
+		errs.Add("teacher_nik", CodeFormat, "must match %s", r.NIK.String())
+	}
+	if len(r.Classes) > 0 && !r.Classes[s.ClassCode] {
+		errs.Add("class_code", CodeUnknown, "unknown class %q", s.ClassCode)
+	}
+	if len(r.Subjects) > 0 && !r.Subjects[s.SubjectCode] {
+		errs.Add("subject_code", CodeUnknown, "unknown subject %q", s.SubjectCode)
+	}
+	return errs
+}
@@ -0,0 +1,95 @@
+package validate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/validate"
+)
+
+func lesson() models.Schedule {
+	return models.Schedule{
+		ClassCode:   "X-1",
+		SubjectCode: "MTK",
+		TeacherNIK:  "12345",
+		Date:        time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), // Monday
+		JamKe:       1,
+		TimeStart:   "07:00",
+		TimeEnd:     "07:45:00",
+	}
+}
+
+func fields(errs validate.Errors) map[string]string {
+	out := map[string]string{}
+	for _, e := range errs {
+		out[e.Field] = e.Code
+	}
+	return out
+}
+
+func TestClock(t *testing.T) {
+	for _, ok := range []string{"00:00", "07:30", "23:59:59"} {
+		assert.True(t, validate.Clock(ok), ok)
+	}
+	for _, bad := range []string{"", "7:30", "24:00", "07:60", "07:30:60", "07.30", "07:30 "} {
+		assert.False(t, validate.Clock(bad), bad)
+	}
+}
+
+func TestScheduleValid(t *testing.T) {
+	r := validate.NewRules("", nil, nil)
+	assert.Nil(t, r.Schedule(lesson()))
+}
+
+func TestScheduleFieldErrors(t *testing.T) {
+	r := validate.NewRules("", nil, nil)
+
+	s := lesson()
+	s.TimeStart = "7 pagi"
+	s.JamKe = -3
+	s.TeacherNIK = "12a45"
+	assert.Equal(t, map[string]string{
+		"time_start":  validate.CodeFormat,
+		"jam_ke":      validate.CodeRange,
+		"teacher_nik": validate.CodeFormat,
+	}, fields(r.Schedule(s)))
+
+	s = lesson()
+	s.TimeStart, s.TimeEnd = "08:00", "07:45"
+	assert.Equal(t, map[string]string{"time_end": validate.CodeOrder}, fields(r.Schedule(s)))
+	s.TimeEnd = "08:00"
+	assert.Equal(t, map[string]string{"time_end": validate.CodeOrder}, fields(r.Schedule(s)))
+}
+
+func TestScheduleBellAndCatalogue(t *testing.T) {
+	periods := []models.BellPeriod{
+		{JamKe: 1, TimeStart: "07:00", TimeEnd: "07:45"},
+		{JamKe: 8, TimeStart: "12:30", TimeEnd: "13:15", Weekdays: []int{1, 2, 3, 4}},
+	}
+	reqs := []models.CurriculumRequirement{{ClassCode: "X-1", SubjectCode: "MTK"}}
+	r := validate.NewRules(`^[0-9]{5}$`, periods, reqs)
+
+	assert.Nil(t, r.Schedule(lesson()))
+
+	s := lesson()
+	s.JamKe = 8
+	assert.Nil(t, r.Schedule(s))
+	s.Date = s.Date.AddDate(0, 0, 4) // Friday
+	assert.Equal(t, map[string]string{"jam_ke": validate.CodeRange}, fields(r.Schedule(s)))
+
+	s = lesson()
+	s.ClassCode, s.SubjectCode, s.TeacherNIK = "XII-9", "ASTRO", "123456"
+	assert.Equal(t, map[string]string{
+		"class_code":   validate.CodeUnknown,
+		"subject_code": validate.CodeUnknown,
+		"teacher_nik":  validate.CodeFormat,
+	}, fields(r.Schedule(s)))
+}
+
+func TestInvalidPatternFallsBack(t *testing.T) {
+	r := validate.NewRules("([", nil, nil)
+	assert.Equal(t, validate.DefaultNIKPattern, r.NIK.String())
+}
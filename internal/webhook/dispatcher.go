@@ -0,0 +1,250 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Ping is the event type of the test delivery sent on request.
+const Ping = "ping"
+
+// lease is how long a claimed delivery is hidden from other claims; it
+// outlasts the client timeout, so a crash mid-attempt only delays it.
+const lease = time.Minute
+
+// Store keeps webhooks and deliveries, see repo.WebhookRepo. Its methods
+// act across schools; every record carries its school ID.
+type Store interface {
+	// Subscribed returns the active webhooks of school that want typ.
+	Subscribed(school, typ string) ([]models.Webhook, error)
+	// Webhook returns a webhook, or nil when it no longer exists.
+	Webhook(school string, id primitive.ObjectID) (*models.Webhook, error)
+	Enqueue(d *models.WebhookDelivery) error
+	// Claim returns the pending delivery due longest, or nil when none
+	// is due, and postpones it by lease.
+	Claim(now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	Save(d *models.WebhookDelivery) error
+}
+
+// Dispatcher turns events into deliveries and sends them.
+type Dispatcher struct {
+	Store  Store
+	Client *http.Client
+	// MaxAttempts is how often a delivery is tried before it is dead.
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the backoff between attempts.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Poll is how often due retries are looked for.
+	Poll time.Duration
+
+	wake chan struct{}
+}
+
+func NewDispatcher(store Store, maxAttempts int, base, max time.Duration) *Dispatcher {
+	return &Dispatcher{
+		Store:       store,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: maxAttempts,
+		BaseDelay:   base,
+		MaxDelay:    max,
+		Poll:        5 * time.Second,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Run queues a delivery for every event on bus that a webhook wants and
+// sends due deliveries, until ctx ends.
+func (d *Dispatcher) Run(ctx context.Context, bus *events.Bus) {
+	ch, stop := bus.Queue(events.Filter{})
+	defer stop()
+	go d.send(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-ch:
+			if err := d.Enqueue(e); err != nil {
+				log.Printf("webhook: queue %s %s: %v", e.Type, e.UUID, err)
+			}
+		}
+	}
+}
+
+// Wake makes the dispatcher look for due deliveries now instead of at the
+// next poll.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context) {
+	ticker := time.NewTicker(d.Poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		d.Drain(ctx)
+	}
+}
+
+// Enqueue stores a pending delivery of e for every webhook of its school
+// that wants its type. A webhook whose delivery cannot be stored does not
+// keep the others from theirs; the errors are joined.
+func (d *Dispatcher) Enqueue(e events.Event) error {
+	hooks, err := d.Store.Subscribed(e.SchoolID, e.Type)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, w := range hooks {
+		if _, err := d.newDelivery(w, e.Type, body); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", w.ID.Hex(), err))
+		}
+	}
+	if len(errs) < len(hooks) {
+		d.Wake()
+	}
+	return errors.Join(errs...)
+}
+
+// SendPing queues a ping delivery to w, for checking a receiver.
+func (d *Dispatcher) SendPing(w models.Webhook) (*models.WebhookDelivery, error) {
+	body, err := json.Marshal(map[string]interface{}{"type": Ping, "webhook_id": w.ID, "at": time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	del, err := d.newDelivery(w, Ping, body)
+	if err != nil {
+		return nil, err
+	}
+	d.Wake()
+	return del, nil
+}
+
+func (d *Dispatcher) newDelivery(w models.Webhook, typ string, body []byte) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	del := &models.WebhookDelivery{
+		SchoolID:      w.SchoolID,
+		WebhookID:     w.ID,
+		EventType:     typ,
+		Payload:       string(body),
+		Status:        models.DeliveryPending,
+		Attempts:      []models.DeliveryAttempt{},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := d.Store.Enqueue(del); err != nil {
+		return nil, err
+	}
+	return del, nil
+}
+
+// Drain attempts every due delivery once and returns how many it
+// attempted.
+func (d *Dispatcher) Drain(ctx context.Context) int {
+	n := 0
+	for ctx.Err() == nil {
+		del, err := d.Store.Claim(time.Now(), lease)
+		if err != nil {
+			log.Println("webhook: claim delivery:", err)
+			return n
+		}
+		if del == nil {
+			return n
+		}
+		d.attempt(ctx, del)
+		n++
+	}
+	return n
+}
+
+// attempt sends del once and records the outcome: delivered, another
+// try after the backoff, or dead once MaxAttempts are used up.
+func (d *Dispatcher) attempt(ctx context.Context, del *models.WebhookDelivery) {
+	w, err := d.Store.Webhook(del.SchoolID, del.WebhookID)
+	if err != nil {
+		// Left claimed; it comes due again when the lease ends.
+		log.Println("webhook: load webhook:", err)
+		return
+	}
+	start := time.Now()
+	a := models.DeliveryAttempt{At: start}
+	if w == nil || !w.Active {
+		a.Error = "webhook was removed or deactivated"
+		del.Attempts = append(del.Attempts, a)
+		del.Status = models.DeliveryDead
+		d.save(del)
+		return
+	}
+	a.StatusCode, err = d.post(ctx, w, del)
+	a.DurationMS = time.Since(start).Milliseconds()
+	del.Attempts = append(del.Attempts, a)
+	switch {
+	case err == nil:
+		del.Status = models.DeliveryDelivered
+		del.DeliveredAt = &a.At
+	case len(del.Attempts) >= d.MaxAttempts:
+		del.Attempts[len(del.Attempts)-1].Error = err.Error()
+		del.Status = models.DeliveryDead
+	default:
+		del.Attempts[len(del.Attempts)-1].Error = err.Error()
+		del.NextAttemptAt = start.Add(Backoff(len(del.Attempts), d.BaseDelay, d.MaxDelay))
+	}
+	d.save(del)
+}
+
+func (d *Dispatcher) save(del *models.WebhookDelivery) {
+	if err := d.Store.Save(del); err != nil {
+		log.Printf("webhook: save delivery %s: %v", del.ID.Hex(), err)
+	}
+}
+
+// post sends the payload of del to w and returns the response status.
+// Any answer other than 2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, w *models.Webhook, del *models.WebhookDelivery) (int, error) {
+	body := []byte(del.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, del.EventType)
+	req.Header.Set(HeaderDelivery, del.ID.Hex())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, now, body))
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,71 @@
+// Package webhook delivers schedule events to subscribed URLs. Each
+// delivery is an HTTP POST of the event as JSON, signed with the
+// webhook's secret, and retried with exponential backoff until it
+// succeeds or runs out of attempts and becomes a dead letter.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers of a delivery.
+const (
+	HeaderEvent     = "X-EGS-Event"
+	HeaderDelivery  = "X-EGS-Delivery"
+	HeaderTimestamp = "X-EGS-Timestamp"
+	HeaderSignature = "X-EGS-Signature"
+)
+
+// Sign returns the signature header value of body sent at ts:
+// "sha256=" and the hex HMAC-SHA256, keyed with secret, of the Unix
+// timestamp, a dot and the body. Signing the timestamp lets receivers
+// reject replays.
+func Sign(secret string, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header against body and the timestamp
+// header, as a receiver would.
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	want := Sign(secret, time.Unix(sec, 0), body)
+	return hmac.Equal([]byte(want), []byte(signature))
+}
+
+// NewSecret returns a random secret for a webhook registered without one.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Backoff is the wait after the given failed attempt (1 for the first):
+// base doubled for every further attempt, capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
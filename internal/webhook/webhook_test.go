@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mghazyfawazh/EGS/internal/events"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+func TestSignVerify(t *testing.T) {
+	ts := time.Unix(1723500000, 0)
+	body := []byte(`{"type":"schedule.created"}`)
+	sig := Sign("s3cret", ts, body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.True(t, Verify("s3cret", "1723500000", sig, body))
+	assert.False(t, Verify("other", "1723500000", sig, body))
+	assert.False(t, Verify("s3cret", "1723500001", sig, body))
+	assert.False(t, Verify("s3cret", "1723500000", sig, []byte(`{}`)))
+	assert.False(t, Verify("s3cret", "x", sig, body))
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+	assert.Equal(t, 10*time.Second, Backoff(1, base, max))
+	assert.Equal(t, 20*time.Second, Backoff(2, base, max))
+	assert.Equal(t, 40*time.Second, Backoff(3, base, max))
+	assert.Equal(t, time.Minute, Backoff(4, base, max))
+	assert.Equal(t, time.Minute, Backoff(40, base, max))
+}
+
+// memStore is a Store in memory.
+type memStore struct {
+	mu         sync.Mutex
+	hooks      []models.Webhook
+	deliveries []*models.WebhookDelivery
+	// broken is a webhook whose deliveries cannot be stored.
+	broken primitive.ObjectID
+}
+
+func (m *memStore) Subscribed(school, typ string) ([]models.Webhook, error) {
+	var out []models.Webhook
+	for _, w := range m.hooks {
+		if w.SchoolID == school && w.Active && w.Wants(typ) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) Webhook(school string, id primitive.ObjectID) (*models.Webhook, error) {
+	for _, w := range m.hooks {
+		if w.SchoolID == school && w.ID == id {
+			return &w, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStore) Enqueue(d *models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.broken.IsZero() && d.WebhookID == m.broken {
+		return errors.New("store down")
+	}
+	d.ID = primitive.NewObjectID()
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *memStore) Claim(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) {
+			d.NextAttemptAt = now.Add(lease)
+			c := *d
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStore) Save(d *models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == d.ID {
+			c := *d
+			m.deliveries[i] = &c
+		}
+	}
+	return nil
+}
+
+func (m *memStore) due() {
+	for _, d := range m.deliveries {
+		d.NextAttemptAt = time.Time{}
+	}
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	hook := models.Webhook{ID: primitive.NewObjectID(), SchoolID: "a", URL: receiver.URL, Secret: "s3cret", Active: true,
+		Events: []string{events.Created}}
+	other := models.Webhook{ID: primitive.NewObjectID(), SchoolID: "b", URL: receiver.URL, Active: true}
+	store := &memStore{hooks: []models.Webhook{hook, other}}
+	d := NewDispatcher(store, 3, time.Second, time.Minute)
+
+	require.NoError(t, d.Enqueue(events.Event{Type: events.Updated, SchoolID: "a", UUID: "u1"}))
+	require.NoError(t, d.Enqueue(events.Event{Type: events.Created, SchoolID: "a", UUID: "u1"}))
+	require.Len(t, store.deliveries, 1, "one school, one wanted type")
+
+	assert.Equal(t, 1, d.Drain(context.Background()))
+	del := store.deliveries[0]
+	assert.Equal(t, models.DeliveryDelivered, del.Status)
+	require.Len(t, del.Attempts, 1)
+	assert.Equal(t, http.StatusOK, del.Attempts[0].StatusCode)
+	require.NotNil(t, got)
+	assert.Equal(t, events.Created, got.Header.Get(HeaderEvent))
+	assert.Equal(t, del.ID.Hex(), got.Header.Get(HeaderDelivery))
+	assert.True(t, Verify("s3cret", got.Header.Get(HeaderTimestamp), got.Header.Get(HeaderSignature), body))
+	assert.Contains(t, string(body), `"uuid":"u1"`)
+}
+
+func TestDispatcherEnqueuesPastBrokenWebhook(t *testing.T) {
+	first := models.Webhook{ID: primitive.NewObjectID(), SchoolID: "a", URL: "http://example.invalid", Active: true}
+	second := models.Webhook{ID: primitive.NewObjectID(), SchoolID: "a", URL: "http://example.invalid", Active: true}
+	store := &memStore{hooks: []models.Webhook{first, second}, broken: first.ID}
+	d := NewDispatcher(store, 3, time.Second, time.Minute)
+
+	err := d.Enqueue(events.Event{Type: events.Created, SchoolID: "a", UUID: "u1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), first.ID.Hex())
+	require.Len(t, store.deliveries, 1)
+	assert.Equal(t, second.ID, store.deliveries[0].WebhookID)
+}
+
+func TestDispatcherRetriesThenDeadLetters(t *testing.T) {
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	hook := models.Webhook{ID: primitive.NewObjectID(), SchoolID: "a", URL: receiver.URL, Active: true}
+	store := &memStore{hooks: []models.Webhook{hook}}
+	d := NewDispatcher(store, 3, time.Hour, 4*time.Hour)
+	_, err := d.SendPing(hook)
+	require.NoError(t, err)
+
+	start := time.Now()
+	assert.Equal(t, 1, d.Drain(context.Background()))
+	del := store.deliveries[0]
+	assert.Equal(t, models.DeliveryPending, del.Status)
+	assert.WithinDuration(t, start.Add(time.Hour), del.NextAttemptAt, time.Minute)
+	assert.Equal(t, 0, d.Drain(context.Background()), "not due before the backoff")
+
+	store.due()
+	d.Drain(context.Background())
+	assert.WithinDuration(t, start.Add(2*time.Hour), store.deliveries[0].NextAttemptAt, time.Minute)
+	store.due()
+	d.Drain(context.Background())
+
+	del = store.deliveries[0]
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, models.DeliveryDead, del.Status)
+	require.Len(t, del.Attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, del.Attempts[2].StatusCode)
+	assert.Contains(t, del.Attempts[2].Error, "503")
+}
+
+func TestDispatcherDeadLettersRemovedWebhook(t *testing.T) {
+	store := &memStore{}
+	d := NewDispatcher(store, 3, time.Second, time.Minute)
+	_, err := d.SendPing(models.Webhook{ID: primitive.NewObjectID(), SchoolID: "a"})
+	require.NoError(t, err)
+	d.Drain(context.Background())
+	assert.Equal(t, models.DeliveryDead, store.deliveries[0].Status)
+}
@@ -0,0 +1,166 @@
+package workload
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mghazyfawazh/EGS/internal/calendar"
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+)
+
+// Limits are the weekly and daily JP bounds of a teacher. Zero disables a
+// bound.
+type Limits struct {
+	MinWeeklyJP float64 `json:"min_weekly_jp"`
+	MaxWeeklyJP float64 `json:"max_weekly_jp"`
+	MaxDailyJP  float64 `json:"max_daily_jp"`
+}
+
+// For returns the limits of t, falling back to def for unset values.
+func For(t *models.Teacher, def Limits) Limits {
+	l := def
+	if t == nil {
+		return l
+	}
+	if t.MinWeeklyJP > 0 {
+		l.MinWeeklyJP = t.MinWeeklyJP
+	}
+	if t.MaxWeeklyJP > 0 {
+		l.MaxWeeklyJP = t.MaxWeeklyJP
+	}
+	if t.MaxDailyJP > 0 {
+		l.MaxDailyJP = t.MaxDailyJP
+	}
+	return l
+}
+
+// Exceeded reports which maximum a teacher would exceed with the given
+// weekly and daily JP, or nil when none.
+func (l Limits) Exceeded(weekJP, dayJP float64) []string {
+	var out []string
+	if l.MaxWeeklyJP > 0 && weekJP > l.MaxWeeklyJP {
+		out = append(out, fmt.Sprintf("weekly workload %g JP exceeds maximum %g JP", weekJP, l.MaxWeeklyJP))
+	}
+	if l.MaxDailyJP > 0 && dayJP > l.MaxDailyJP {
+		out = append(out, fmt.Sprintf("daily workload %g JP exceeds maximum %g JP", dayJP, l.MaxDailyJP))
+	}
+	return out
+}
+
+// Load sums the JP of rows for the week and the day of date.
+func Load(rows []models.Schedule, calc jp.Calculator, date time.Time) (weekJP, dayJP float64) {
+	ws := calendar.WeekStart(date)
+	we := ws.AddDate(0, 0, 7)
+	for _, r := range rows {
+		if r.Date.Before(ws) || !r.Date.Before(we) {
+			continue
+		}
+		v := calc.Of(r)
+		weekJP += v
+		if r.Date.Equal(date) {
+			dayJP += v
+		}
+	}
+	return weekJP, dayJP
+}
+
+// Status values of a week or teacher.
+const (
+	StatusOK      = "ok"
+	StatusUnder   = "under"
+	StatusOver    = "over"
+	StatusPartial = "partial"
+)
+
+type Week struct {
+	Start      string  `json:"start"`
+	End        string  `json:"end"`
+	JP         float64 `json:"jp"`
+	MaxDailyJP float64 `json:"max_daily_jp"`
+	Status     string  `json:"status"`
+}
+
+type TeacherLoad struct {
+	TeacherNIK  string  `json:"teacher_nik"`
+	TeacherName string  `json:"teacher_name"`
+	Limits      Limits  `json:"limits"`
+	TotalJP     float64 `json:"total_jp"`
+	Weeks       []Week  `json:"weeks"`
+	Status      string  `json:"status"`
+}
+
+// Dashboard evaluates every teacher in rows or profiles over [start, end].
+// Weeks clipped by the range shorter than a school week (Monday-Saturday)
+// are marked partial and never count as under-loaded.
+func Dashboard(rows []models.Schedule, profiles []models.Teacher, calc jp.Calculator, def Limits, start, end time.Time) []TeacherLoad {
+	weeks := calendar.Weeks(start, end)
+	byNIK := map[string]*TeacherLoad{}
+	daily := map[string]map[time.Time]float64{}
+	profileOf := map[string]*models.Teacher{}
+	for i := range profiles {
+		profileOf[profiles[i].NIK] = &profiles[i]
+	}
+
+	get := func(nik, name string) *TeacherLoad {
+		if tl, ok := byNIK[nik]; ok {
+			return tl
+		}
+		tl := &TeacherLoad{TeacherNIK: nik, TeacherName: name, Limits: For(profileOf[nik], def)}
+		for _, w := range weeks {
+			tl.Weeks = append(tl.Weeks, Week{Start: w.Start.Format("2006-01-02"), End: w.End.Format("2006-01-02")})
+		}
+		byNIK[nik] = tl
+		daily[nik] = map[time.Time]float64{}
+		return tl
+	}
+	for _, p := range profiles {
+		get(p.NIK, p.Name)
+	}
+
+	for _, r := range rows {
+		i := calendar.WeekIndex(start, r.Date)
+		if i < 0 || i >= len(weeks) {
+			continue
+		}
+		tl := get(r.TeacherNIK, r.TeacherName)
+		v := calc.Of(r)
+		tl.Weeks[i].JP += v
+		tl.TotalJP += v
+		daily[r.TeacherNIK][r.Date] += v
+	}
+
+	out := make([]TeacherLoad, 0, len(byNIK))
+	for nik, tl := range byNIK {
+		for d, v := range daily[nik] {
+			i := calendar.WeekIndex(start, d)
+			if v > tl.Weeks[i].MaxDailyJP {
+				tl.Weeks[i].MaxDailyJP = v
+			}
+		}
+		tl.Status = StatusOK
+		for i := range tl.Weeks {
+			w := &tl.Weeks[i]
+			full := weeks[i].Full()
+			switch {
+			case len(tl.Limits.Exceeded(w.JP, w.MaxDailyJP)) > 0:
+				w.Status = StatusOver
+			case !full:
+				w.Status = StatusPartial
+			case tl.Limits.MinWeeklyJP > 0 && w.JP < tl.Limits.MinWeeklyJP:
+				w.Status = StatusUnder
+			default:
+				w.Status = StatusOK
+			}
+			if w.Status == StatusOver {
+				tl.Status = StatusOver
+			} else if w.Status == StatusUnder && tl.Status == StatusOK {
+				tl.Status = StatusUnder
+			}
+		}
+		out = append(out, *tl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TeacherNIK < out[j].TeacherNIK })
+	return out
+}
@@ -0,0 +1,70 @@
+package workload_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mghazyfawazh/EGS/internal/jp"
+	"github.com/mghazyfawazh/EGS/internal/models"
+	"github.com/mghazyfawazh/EGS/internal/workload"
+)
+
+var calc = jp.Calculator{LessonMinutes: 45, Rounding: jp.RoundNearest}
+
+// 2024-01-01 is a Monday.
+func d(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+
+func lesson(nik string, day int, start, end string) models.Schedule {
+	return models.Schedule{TeacherNIK: nik, Date: d(day), TimeStart: start, TimeEnd: end}
+}
+
+func TestLoadAndExceeded(t *testing.T) {
+	rows := []models.Schedule{
+		lesson("1", 1, "07:00:00", "08:30:00"),
+		lesson("1", 2, "07:00:00", "08:30:00"),
+		lesson("1", 8, "07:00:00", "08:30:00"),
+	}
+
+	week, day := workload.Load(rows, calc, d(1))
+	assert.Equal(t, 4.0, week)
+	assert.Equal(t, 2.0, day)
+
+	l := workload.Limits{MaxWeeklyJP: 4, MaxDailyJP: 2}
+	assert.Empty(t, l.Exceeded(4, 2))
+	assert.Equal(t, []string{
+		"weekly workload 5 JP exceeds maximum 4 JP",
+		"daily workload 3 JP exceeds maximum 2 JP",
+	}, l.Exceeded(5, 3))
+}
+
+func TestFor(t *testing.T) {
+	def := workload.Limits{MinWeeklyJP: 24, MaxWeeklyJP: 40}
+	assert.Equal(t, def, workload.For(nil, def))
+	assert.Equal(t, workload.Limits{MinWeeklyJP: 12, MaxWeeklyJP: 40, MaxDailyJP: 6},
+		workload.For(&models.Teacher{MinWeeklyJP: 12, MaxDailyJP: 6}, def))
+}
+
+func TestDashboard(t *testing.T) {
+	rows := []models.Schedule{
+		lesson("1", 1, "07:00:00", "10:00:00"), // 4 JP
+		lesson("2", 1, "07:00:00", "07:45:00"), // 1 JP
+	}
+	profiles := []models.Teacher{{NIK: "3", Name: "Joko"}}
+	def := workload.Limits{MinWeeklyJP: 2, MaxWeeklyJP: 3}
+
+	// Monday 1st to Wednesday 10th: one full week, one partial.
+	loads := workload.Dashboard(rows, profiles, calc, def, d(1), d(10))
+	assert.Len(t, loads, 3)
+
+	assert.Equal(t, workload.StatusOver, loads[0].Status)
+	assert.Equal(t, workload.StatusOver, loads[0].Weeks[0].Status)
+	assert.Equal(t, workload.StatusPartial, loads[0].Weeks[1].Status)
+
+	assert.Equal(t, workload.StatusUnder, loads[1].Status)
+	assert.Equal(t, 1.0, loads[1].TotalJP)
+
+	assert.Equal(t, "Joko", loads[2].TeacherName)
+	assert.Equal(t, workload.StatusUnder, loads[2].Status)
+}